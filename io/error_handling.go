@@ -1,6 +1,8 @@
 package io
 
 import (
+	"time"
+
 	"github.com/primetalk/goio/fun"
 	"github.com/primetalk/goio/option"
 )
@@ -83,3 +85,17 @@ func RetryStrategyMaxCount(substring string) func(s int, err error) IO[option.Op
 		})
 	}
 }
+
+// RetryStrategyExponentialBackoff is a strategy that retries at most maxCount times.
+// Before each retry it sleeps. The first sleep is initialDelay and every next
+// one is twice as long as the previous.
+// The state is the number of retries that have already been performed. Start with 0.
+func RetryStrategyExponentialBackoff(initialDelay time.Duration, maxCount int) func(s int, err error) IO[option.Option[int]] {
+	return func(s int, err error) IO[option.Option[int]] {
+		if s >= maxCount {
+			return Lift(option.None[int]())
+		} else {
+			return SleepA(initialDelay<<s, option.Some(s+1))
+		}
+	}
+}
@@ -2,6 +2,7 @@ package io_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/primetalk/goio/fun"
 	"github.com/primetalk/goio/io"
@@ -39,3 +40,23 @@ func TestRetry(t *testing.T) {
 	retried3 := io.Retry(incFail, io.RetryStrategyMaxCount("expected"), 3)
 	assert.Equal(t, fun.Unit1, UnsafeIO(t, retried3))
 }
+
+func TestRetryStrategyExponentialBackoff(t *testing.T) {
+	i := -3
+	incFail := io.FromUnit(func() error {
+		i += 1
+		if i >= 0 {
+			return nil
+		} else {
+			return errExpected
+		}
+	})
+	retried, duration := UnsafeIO(t, io.MeasureDuration(
+		io.RetryS(incFail, io.RetryStrategyExponentialBackoff(10*time.Millisecond, 5), 0),
+	)).Both()
+	assert.Equal(t, fun.NewPair(fun.Unit1, 2), retried)
+	assert.GreaterOrEqual(t, duration, 30*time.Millisecond)
+
+	exhausted := io.Retry(io.Fail[int](errExpected), io.RetryStrategyExponentialBackoff(time.Millisecond, 2), 0)
+	UnsafeIOExpectError(t, errExpected, exhausted)
+}
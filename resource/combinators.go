@@ -0,0 +1,90 @@
+package resource
+
+import (
+	"log"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/slice"
+)
+
+// Both combines two resources. The second resource is acquired after the first one.
+// They are released in reverse order.
+// If the second resource fails during acquisition, the first one is released.
+func Both[A any, B any](ra Resource[A], rb Resource[B]) Resource[fun.Pair[A, B]] {
+	return FlatMap(ra, func(a A) Resource[fun.Pair[A, B]] {
+		return Map(rb, func(b B) fun.Pair[A, B] {
+			return fun.NewPair(a, b)
+		})
+	})
+}
+
+// Sequence acquires the resources one after another.
+// They are released in reverse order.
+// If some resource fails during acquisition, the already acquired ones are released.
+func Sequence[A any](resources []Resource[A]) (res Resource[[]A]) {
+	res = NewResource(io.Lift([]A{}), fun.Const[[]A](io.IOUnit1))
+	for _, ra := range resources {
+		raCopy := ra // See https://eli.thegreenplace.net/2019/go-internals-capturing-loop-variables-in-closures/
+		res = FlatMap(res, func(as []A) Resource[[]A] {
+			return Map(raCopy, func(a A) []A {
+				return append(as, a)
+			})
+		})
+	}
+	return
+}
+
+// ParSequence acquires the resources in parallel.
+// If some resources fail during acquisition, the successfully acquired ones are released
+// and the first error is returned.
+// The resources are released in reverse order. All of them are released even if some release fails.
+func ParSequence[A any](resources []Resource[A]) Resource[[]A] {
+	acquires := slice.Map(resources, func(ra Resource[A]) io.IO[io.GoResult[Closable[A]]] {
+		return io.FoldToGoResult(io.IO[Closable[A]](ra))
+	})
+	return Resource[[]A](io.FlatMap(io.Parallel(acquires...), func(results []io.GoResult[Closable[A]]) io.IO[Closable[[]A]] {
+		var acquired []Closable[A]
+		var err error
+		for _, r := range results {
+			if r.Error == nil {
+				acquired = append(acquired, r.Value)
+			} else if err == nil {
+				err = r.Error
+			}
+		}
+		if err != nil {
+			return io.AndThen(releaseLoggingErrors(closeAll(acquired)), io.Fail[Closable[[]A]](err))
+		}
+		return io.Lift(Closable[[]A]{
+			Value: slice.Map(acquired, func(ca Closable[A]) A { return ca.Value }),
+			Close: func() io.IOUnit { return closeAll(acquired) },
+		})
+	}))
+}
+
+// closeAll closes all closables in reverse order and returns the first error.
+func closeAll[A any](closables []Closable[A]) io.IOUnit {
+	return io.FromUnit(func() (err error) {
+		for i := len(closables) - 1; i >= 0; i-- {
+			_, err2 := io.UnsafeRunSync(closables[i].Close())
+			if err2 != nil {
+				if err == nil {
+					err = err2
+				} else {
+					log.Printf("double error during resource release: %+v", err2)
+				}
+			}
+		}
+		return
+	})
+}
+
+// Allocated acquires the resource and returns it together with the release action.
+// The caller becomes responsible for running the release action.
+// This is useful when the lifetime of a resource doesn't fit into Use.
+func Allocated[A any](ra Resource[A]) io.IO[fun.Pair[A, io.IOUnit]] {
+	return io.Map(io.IO[Closable[A]](ra), func(ca Closable[A]) fun.Pair[A, io.IOUnit] {
+		return fun.NewPair(ca.Value, io.Delay(ca.Close))
+	})
+}
@@ -0,0 +1,94 @@
+package resource_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+// tracker records acquisitions and releases of test resources.
+type tracker struct {
+	mutex    sync.Mutex
+	acquired []string
+	released []string
+}
+
+func (t *tracker) resource(name string) resource.Resource[string] {
+	return resource.NewResource(
+		io.Pure(func() string {
+			t.mutex.Lock()
+			defer t.mutex.Unlock()
+			t.acquired = append(t.acquired, name)
+			return name
+		}),
+		func(s string) io.IOUnit {
+			return io.FromPureEffect(func() {
+				t.mutex.Lock()
+				defer t.mutex.Unlock()
+				t.released = append(t.released, s)
+			})
+		},
+	)
+}
+
+var errAcquire = errors.New("acquire error")
+
+func TestBoth(t *testing.T) {
+	tr := &tracker{}
+	res, err := io.UnsafeRunSync(resource.Use(resource.Both(tr.resource("a"), tr.resource("b")), func(p fun.Pair[string, string]) io.IO[string] {
+		return io.Lift(p.V1 + p.V2)
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "ab", res)
+	assert.Equal(t, []string{"b", "a"}, tr.released)
+
+	tr = &tracker{}
+	_, err = io.UnsafeRunSync(resource.Use(resource.Both(tr.resource("a"), resource.Fail[string](errAcquire)), func(p fun.Pair[string, string]) io.IO[string] {
+		return io.Lift(p.V1)
+	}))
+	assert.ErrorIs(t, err, errAcquire)
+	assert.Equal(t, []string{"a"}, tr.released)
+}
+
+func TestSequence(t *testing.T) {
+	tr := &tracker{}
+	res, err := io.UnsafeRunSync(resource.Use(resource.Sequence([]resource.Resource[string]{tr.resource("a"), tr.resource("b"), tr.resource("c")}), io.Lift[[]string]))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, res)
+	assert.Equal(t, []string{"c", "b", "a"}, tr.released)
+
+	tr = &tracker{}
+	_, err = io.UnsafeRunSync(resource.Use(resource.Sequence([]resource.Resource[string]{tr.resource("a"), tr.resource("b"), resource.Fail[string](errAcquire), tr.resource("d")}), io.Lift[[]string]))
+	assert.ErrorIs(t, err, errAcquire)
+	assert.Equal(t, []string{"a", "b"}, tr.acquired)
+	assert.Equal(t, []string{"b", "a"}, tr.released)
+}
+
+func TestParSequence(t *testing.T) {
+	tr := &tracker{}
+	res, err := io.UnsafeRunSync(resource.Use(resource.ParSequence([]resource.Resource[string]{tr.resource("a"), tr.resource("b"), tr.resource("c")}), io.Lift[[]string]))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, res)
+	assert.Equal(t, []string{"c", "b", "a"}, tr.released)
+
+	tr = &tracker{}
+	_, err = io.UnsafeRunSync(resource.Use(resource.ParSequence([]resource.Resource[string]{tr.resource("a"), resource.Fail[string](errAcquire), tr.resource("c")}), io.Lift[[]string]))
+	assert.ErrorIs(t, err, errAcquire)
+	assert.ElementsMatch(t, []string{"a", "c"}, tr.released)
+}
+
+func TestAllocated(t *testing.T) {
+	tr := &tracker{}
+	allocated, err := io.UnsafeRunSync(resource.Allocated(tr.resource("a")))
+	assert.NoError(t, err)
+	assert.Equal(t, "a", allocated.V1)
+	assert.Empty(t, tr.released)
+	_, err = io.UnsafeRunSync(allocated.V2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, tr.released)
+}
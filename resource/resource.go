@@ -107,14 +107,29 @@ func FlatMap[A any, B any](ra Resource[A], f func(a A) Resource[B]) Resource[B]
 }
 
 // ClosableIOTransform transforms a closable of io closable to just io closable.
+// When the inner closable fails, the outer one is closed.
 func ClosableIOTransform[A any](cioca Closable[io.IO[Closable[A]]]) (ioca io.IO[Closable[A]]) {
-	return io.Eval(func() (ca Closable[A], err error) {
-		defer fun.RecoverToErrorVar("resource.ClosableIOTransform", &err)
-		ca = ClosableFlatMap(cioca, func(ioca io.IO[Closable[A]]) (ca1 Closable[A]) {
-			ca1, err = io.UnsafeRunSync(ioca)
+	return io.Recover(
+		io.Eval(func() (ca Closable[A], err error) {
+			defer fun.RecoverToErrorVar("resource.ClosableIOTransform", &err)
+			ca = ClosableFlatMap(cioca, func(ioca io.IO[Closable[A]]) (ca1 Closable[A]) {
+				ca1, err = io.UnsafeRunSync(ioca)
+				return
+			})
 			return
-		})
-		return
+		}),
+		func(err error) io.IO[Closable[A]] {
+			return io.AndThen(releaseLoggingErrors(cioca.Close()), io.Fail[Closable[A]](err))
+		},
+	)
+}
+
+// releaseLoggingErrors releases a resource when there is already another error.
+// The release error is only logged.
+func releaseLoggingErrors(release io.IOUnit) io.IOUnit {
+	return io.Recover(release, func(err2 error) io.IOUnit {
+		log.Printf("double error during resource release: %+v", err2)
+		return io.IOUnit1
 	})
 }
 
@@ -97,3 +97,21 @@ func TestResourceInResource(t *testing.T) {
 	assert.Equal(t, err, nil)
 	assert.Equal(t, res18, 18)
 }
+
+func TestResourceInResourceFail(t *testing.T) {
+	expectedErr := errors.New("some error")
+	released := false
+	res1 := resource.NewResource(
+		io.Lift("resource1"),
+		func(s string) io.IO[fun.Unit] {
+			released = true
+			return io.IOUnit1
+		},
+	)
+	res2 := resource.FlatMap(res1, func(s string) resource.Resource[string] {
+		return resource.Fail[string](expectedErr)
+	})
+	_, err := io.UnsafeRunSync(resource.Use(res2, io.Lift[string]))
+	assert.Equal(t, expectedErr, err)
+	assert.True(t, released)
+}
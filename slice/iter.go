@@ -0,0 +1,24 @@
+//go:build go1.23
+
+package slice
+
+import "iter"
+
+// FromSeq collects all elements of the sequence into a slice.
+func FromSeq[A any](seq iter.Seq[A]) (as []A) {
+	for a := range seq {
+		as = append(as, a)
+	}
+	return
+}
+
+// ToSeq returns a sequence of the slice elements.
+func ToSeq[A any](as []A) iter.Seq[A] {
+	return func(yield func(A) bool) {
+		for _, a := range as {
+			if !yield(a) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+//go:build go1.23
+
+package slice_test
+
+import (
+	"maps"
+	"sort"
+	"testing"
+
+	"github.com/primetalk/goio/slice"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeq(t *testing.T) {
+	nats := slice.Nats(5)
+	assert.Equal(t, nats, slice.FromSeq(slice.ToSeq(nats)))
+	keys := slice.FromSeq(maps.Keys(map[string]int{"a": 1, "b": 2}))
+	sort.Strings(keys)
+	assert.Equal(t, []string{"a", "b"}, keys)
+	for i := range slice.ToSeq(nats) {
+		if i > 2 {
+			break
+		}
+		assert.LessOrEqual(t, i, 2)
+	}
+}
@@ -0,0 +1,63 @@
+package stream
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/slice"
+)
+
+var errAllWorkersHaveStopped = errors.New("not-an-error: all workers have stopped")
+
+// Balance distributes each element of the stream to exactly one of n workers.
+// An element is given to the first worker that is ready to receive it.
+// Hence, a slow worker doesn't block the others.
+// When the stream completes, streams of all workers complete.
+// When all workers stop, the rest of the stream is finalized.
+// If the stream or any worker fails, the result fails.
+// Values of n less than 1 mean a single worker.
+func Balance[A any, B any](stm Stream[A], n int, handler func(Stream[A]) io.IO[B]) io.IO[[]B] {
+	if n < 1 {
+		n = 1
+	}
+	return io.Delay(func() io.IO[[]B] {
+		ch := make(chan A)
+		stopped := make(chan struct{})
+		var mu sync.Mutex
+		running := n
+		workerStopped := io.FromPureEffect(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			running -= 1
+			if running == 0 {
+				close(stopped)
+			}
+		})
+		workers := slice.Map(slice.Range(0, n), func(int) io.IO[B] {
+			return io.Finally(handler(FromChannel(ch)), workerStopped)
+		})
+		send := DrainAll(MapEval(stm, func(a A) io.IOUnit {
+			return io.FromUnit(func() error {
+				select {
+				case ch <- a:
+					return nil
+				case <-stopped:
+					return errAllWorkersHaveStopped
+				}
+			})
+		}))
+		producer := io.Recover(
+			io.Finally(send, io.CloseChannel[A](ch)),
+			func(err error) io.IOUnit {
+				if err == errAllWorkersHaveStopped {
+					return io.IOUnit1
+				} else {
+					return io.Fail[fun.Unit](err)
+				}
+			},
+		)
+		return io.Map(io.PairParallel(producer, io.Parallel(workers...)), fun.PairV2[fun.Unit, []B])
+	})
+}
@@ -1,10 +1,13 @@
 package stream_test
 
 import (
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/primetalk/goio/fun"
 	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/resource"
 	"github.com/primetalk/goio/stream"
 	"github.com/stretchr/testify/assert"
 )
@@ -40,3 +43,71 @@ func TestStreamConversion(t *testing.T) {
 	})
 	UnsafeIO(t, io2)
 }
+
+func TestPairOfChannelsToPipeFailure(t *testing.T) {
+	ch := make(chan int)
+	pipe := stream.PairOfChannelsToPipe(ch, ch)
+	UnsafeIOExpectError(t, errExpected, stream.ToSlice(stream.Through(natsAndThenFail, pipe)))
+}
+
+func TestPairOfChannelsToPipeAbandoned(t *testing.T) {
+	var count int32
+	finalizer := io.FromPureEffect(func() { atomic.AddInt32(&count, 1) })
+	ch := make(chan int)
+	pipe := stream.PairOfChannelsToPipe(ch, ch)
+	res := stream.Take(stream.Through(stream.OnFinalize(nats, finalizer), pipe), 3)
+	assert.Equal(t, []int{1, 2, 3}, UnsafeStreamToSlice(t, res))
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&count) == 1 }, time.Second, time.Millisecond)
+}
+
+func TestChannelBufferPipeFailure(t *testing.T) {
+	buffered := stream.Through(natsAndThenFail, stream.ChannelBufferPipe[int](5))
+	values := []int{}
+	err := stream.ForEach(buffered, func(i int) { values = append(values, i) })
+	UnsafeIOExpectError(t, errExpected, err)
+	assert.Equal(t, nats10Values, values)
+}
+
+func TestToStreamEventChannels(t *testing.T) {
+	ch1 := make(chan stream.StreamEvent[int], 20)
+	ch2 := make(chan stream.StreamEvent[int], 20)
+	UnsafeIO(t, stream.ToStreamEventChannels(natsAndThenFail, ch1, ch2))
+	for _, ch := range []chan stream.StreamEvent[int]{ch1, ch2} {
+		values := []int{}
+		err := stream.ForEach(stream.FromStreamEventChannel(ch), func(i int) { values = append(values, i) })
+		UnsafeIOExpectError(t, errExpected, err)
+		assert.Equal(t, nats10Values, values)
+	}
+}
+
+func TestPipeToPairOfStreamEventChannels(t *testing.T) {
+	failAfter2 := func(stm stream.Stream[int]) stream.Stream[int] {
+		return stream.AndThen(stream.Take(stm, 2), failedStream)
+	}
+	values := []int{}
+	err := resource.Use(stream.PipeToPairOfStreamEventChannels(failAfter2), func(pair fun.Pair[chan<- stream.StreamEvent[int], <-chan stream.StreamEvent[int]]) io.IOUnit {
+		go func() {
+			// the pipe reads only two elements
+			pair.V1 <- stream.NewStreamEvent(1)
+			pair.V1 <- stream.NewStreamEvent(2)
+		}()
+		return stream.ForEach(stream.FromStreamEventChannel(pair.V2), func(i int) { values = append(values, i) })
+	})
+	UnsafeIOExpectError(t, errExpected, err)
+	assert.Equal(t, []int{1, 2}, values)
+}
+
+func TestPipeToPairOfStreamEventChannelsAbandoned(t *testing.T) {
+	var count int32
+	finalizer := io.FromPureEffect(func() { atomic.AddInt32(&count, 1) })
+	withFinalizer := func(stm stream.Stream[int]) stream.Stream[int] {
+		return stream.OnFinalize(stream.AndThen(stm, nats), finalizer)
+	}
+	first := UnsafeIO(t, resource.Use(stream.PipeToPairOfStreamEventChannels(withFinalizer), func(pair fun.Pair[chan<- stream.StreamEvent[int], <-chan stream.StreamEvent[int]]) io.IO[int] {
+		close(pair.V1)
+		// only the first element is read from the output channel
+		return stream.Head(stream.FromStreamEventChannel(pair.V2))
+	}))
+	assert.Equal(t, 1, first)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
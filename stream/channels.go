@@ -1,8 +1,11 @@
 package stream
 
 import (
+	"sync"
+
 	"github.com/primetalk/goio/fun"
 	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/resource"
 	"github.com/primetalk/goio/slice"
 )
 
@@ -25,7 +28,8 @@ func ToChannel[A any](stm Stream[A], ch chan<- A) io.IO[fun.Unit] {
 
 // ToChannels sends each stream element to every given channel.
 // Failure or completion of the stream leads to closure of all channels.
-// TODO: Send failure to the channels.
+// NB! The failure cannot be communicated via channels of type A.
+// Use ToStreamEventChannels to deliver failures to the readers.
 func ToChannels[A any](stm Stream[A], channels ...chan<- A) io.IO[fun.Unit] {
 	stmUnits := MapEval(stm,
 		func(a A) io.IO[fun.Unit] {
@@ -65,18 +69,69 @@ func FromChannel[A any](ch <-chan A) Stream[A] {
 // It first starts a separate go routine that will continuously run
 // the input stream and send all it's contents to the `input` channel.
 // The current thread is left with reading from the output channel.
+// When the input stream fails, the `input` channel is closed and
+// the resulting stream fails with the same error after the `output` channel is closed.
+// When the resulting stream is abandoned, the go routine stops, the rest of the input stream
+// is finalized and the `input` channel is closed.
 func PairOfChannelsToPipe[A any, B any](input chan A, output chan B) Pipe[A, B] {
 	return func(stmA Stream[A]) Stream[B] {
-		return FlatMap(
-			Eval(io.FireAndForget(ToChannel(stmA, input))),
-			func(fun.Unit) Stream[B] {
-				return FromChannel(output)
+		return Stream[B](io.Delay(func() io.IO[StepResult[B]] {
+			done := make(chan struct{})
+			var mu sync.Mutex
+			var failure error
+			send := io.Recover(sendToChannel(stmA, input, done), func(err error) io.IOUnit {
+				return io.FromPureEffect(func() {
+					mu.Lock()
+					failure = err
+					mu.Unlock()
+				})
 			})
+			checkFailure := func() Stream[B] {
+				mu.Lock()
+				defer mu.Unlock()
+				if failure == nil {
+					return Empty[B]()
+				} else {
+					return Fail[B](failure)
+				}
+			}
+			stop := io.FromPureEffect(func() { close(done) })
+			return io.AndThen(
+				io.FireAndForget(io.Finally(send, io.CloseChannel[A](input))),
+				io.IO[StepResult[B]](OnFinalize(AndThenLazy(FromChannel(output), checkFailure), stop)),
+			)
+		}))
+	}
+}
+
+// PairOfStreamEventChannelsToPipe converts two channels of stream events
+// that are being used to talk to some external process into a single pipe.
+// All elements of the input stream followed by the finish or error event
+// are sent to the `input` channel in a separate go routine. Then the channel is closed.
+// The resulting stream reads events from the `output` channel.
+// When the resulting stream is abandoned, the go routine stops, the rest of the input stream
+// is finalized and the `input` channel is closed.
+func PairOfStreamEventChannelsToPipe[A any, B any](input chan<- StreamEvent[A], output <-chan StreamEvent[B]) Pipe[A, B] {
+	return func(stmA Stream[A]) Stream[B] {
+		return Stream[B](io.Delay(func() io.IO[StepResult[B]] {
+			done := make(chan struct{})
+			send := sendToChannel(ToStreamEvent(stmA), input, done)
+			stop := io.FromPureEffect(func() { close(done) })
+			return io.AndThen(
+				io.FireAndForget(io.Finally(send, io.CloseChannel(input))),
+				io.IO[StepResult[B]](OnFinalize(FromStreamEventChannel(output), stop)),
+			)
+		}))
 	}
 }
 
 // PipeToPairOfChannels converts a streaming pipe to a pair of channels that could be used
 // to interact with external systems.
+// NB! The failure of the pipe cannot be communicated via channel of type B.
+// The output channel is closed in that case.
+//
+// Deprecated: The failure of the pipe looks like a normal completion and the go routine
+// cannot be stopped. Use PipeToPairOfStreamEventChannels instead.
 func PipeToPairOfChannels[A any, B any](pipe Pipe[A, B]) io.IO[fun.Pair[chan<- A, <-chan B]] {
 	return io.Delay(func() io.IO[fun.Pair[chan<- A, <-chan B]] {
 
@@ -92,19 +147,96 @@ func PipeToPairOfChannels[A any, B any](pipe Pipe[A, B]) io.IO[fun.Pair[chan<- A
 	})
 }
 
+// PipeToPairOfStreamEventChannels converts a streaming pipe to a pair of channels of stream events.
+// The input stream of the pipe is read from the first channel until the finish or error event
+// or until the channel is closed.
+// All elements of the output stream followed by the finish or error event
+// are sent to the second channel in a separate go routine. Then the channel is closed.
+// When the resource is released, the go routine stops, the rest of the output stream
+// is finalized and the input channel is no longer read. Release waits for the go routine to finish.
+func PipeToPairOfStreamEventChannels[A any, B any](pipe Pipe[A, B]) resource.Resource[fun.Pair[chan<- StreamEvent[A], <-chan StreamEvent[B]]] {
+	start := io.Delay(func() io.IO[streamEventPipe[A, B]] {
+		p := streamEventPipe[A, B]{
+			input:  make(chan StreamEvent[A]),
+			output: make(chan StreamEvent[B]),
+			done:   make(chan struct{}),
+		}
+		outputStream := pipe(fromStreamEventChannelUntil(p.input, p.done))
+		send := io.Finally(sendToChannel(ToStreamEvent(outputStream), p.output, p.done), io.CloseChannel(p.output))
+		return io.Map(io.Start(send), func(fiber io.Fiber[fun.Unit]) streamEventPipe[A, B] {
+			p.sender = fiber
+			return p
+		})
+	})
+	stop := func(p streamEventPipe[A, B]) io.IOUnit {
+		return io.AndThen(io.FromPureEffect(func() { close(p.done) }), p.sender.Join())
+	}
+	return resource.Map(resource.NewResource(start, stop), func(p streamEventPipe[A, B]) fun.Pair[chan<- StreamEvent[A], <-chan StreamEvent[B]] {
+		return fun.Pair[chan<- StreamEvent[A], <-chan StreamEvent[B]]{V1: p.input, V2: p.output}
+	})
+}
+
+// streamEventPipe is the state of PipeToPairOfStreamEventChannels.
+type streamEventPipe[A any, B any] struct {
+	input  chan StreamEvent[A]
+	output chan StreamEvent[B]
+	done   chan struct{}
+	sender io.Fiber[fun.Unit]
+}
+
+// fromStreamEventChannelUntil reads stream events from the channel like FromStreamEventChannel.
+// The stream finishes when `done` is closed.
+func fromStreamEventChannelUntil[A any](ch <-chan StreamEvent[A], done <-chan struct{}) Stream[A] {
+	return FromStepResult(
+		io.Eval(func() (sra StepResult[A], err error) {
+			select {
+			case e, ok := <-ch:
+				if !ok || e.IsFinished {
+					sra = NewStepResultFinished[A]()
+				} else if e.Error != nil {
+					err = e.Error
+				} else {
+					sra = NewStepResult(e.Value, fromStreamEventChannelUntil(ch, done))
+				}
+			case <-done:
+				sra = NewStepResultFinished[A]()
+			}
+			return
+		}),
+	)
+}
+
 // ChannelBufferPipe puts incoming values into a buffer of the given size and
 // then reads from that same buffer.
 // This buffer allows to decouple producer and consumer to some extent.
+// Failure of the incoming stream is delivered after the buffered values.
 func ChannelBufferPipe[A any](size int) Pipe[A, A] {
 	return func(sa Stream[A]) Stream[A] {
-		sa1 := Map(sa, func(a A) A {
-			return a
-		})
-		sgra := FoldToGoResult(sa1)
-		ch := make(chan io.GoResult[A], size)
-		pipe := PairOfChannelsToPipe(ch, ch)
-		sgra2 := pipe(sgra)
-		sa2 := UnfoldGoResult(sgra2, Fail[A])
-		return sa2
+		return Stream[A](io.Delay(func() io.IO[StepResult[A]] {
+			ch := make(chan StreamEvent[A], size)
+			return io.IO[StepResult[A]](PairOfStreamEventChannelsToPipe(ch, ch)(sa))
+		}))
 	}
 }
+
+// sendToChannel sends elements of the stream to the channel until the stream finishes
+// or `done` is closed. In the latter case the rest of the stream is finalized.
+func sendToChannel[A any](stm Stream[A], ch chan<- A, done <-chan struct{}) io.IOUnit {
+	return io.FlatMap(io.IO[StepResult[A]](stm), func(sra StepResult[A]) io.IOUnit {
+		sra = uncons(sra)
+		if sra.IsFinished {
+			return io.IOUnit1
+		} else if sra.HasValue {
+			return io.Delay(func() io.IOUnit {
+				select {
+				case ch <- sra.Value:
+					return sendToChannel(sra.Continuation, ch, done)
+				case <-done:
+					return finalizer(sra)
+				}
+			})
+		} else {
+			return sendToChannel(sra.Continuation, ch, done)
+		}
+	})
+}
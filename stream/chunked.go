@@ -0,0 +1,84 @@
+package stream
+
+import (
+	"github.com/primetalk/goio/io"
+)
+
+// NewStepResultChunk constructs StepResult that contains a few values at once.
+// Empty chunk is equivalent to an empty step.
+func NewStepResultChunk[A any](chunk []A, continuation Stream[A]) StepResult[A] {
+	if len(chunk) == 0 {
+		return NewStepResultEmpty(continuation)
+	} else {
+		return StepResult[A]{
+			Chunk:        chunk,
+			Continuation: continuation,
+			IsFinished:   false,
+		}
+	}
+}
+
+// LiftChunk returns a stream that delivers all elements of the slice in a single step.
+// NB! The slice is not copied and should not be modified afterwards.
+func LiftChunk[A any](chunk []A) Stream[A] {
+	return FromStepResult(io.Lift(NewStepResultChunk(chunk, Empty[A]())))
+}
+
+// Chunks exposes the internal chunks of the stream.
+// Single values are converted to chunks of one element.
+func Chunks[A any](stm Stream[A]) Stream[[]A] {
+	return Stream[[]A](io.Map(
+		io.IO[StepResult[A]](stm),
+		func(sra StepResult[A]) (srb StepResult[[]A]) {
+			if sra.IsFinished {
+				srb = NewStepResultFinished[[]A]()
+			} else if len(sra.Chunk) > 0 {
+				srb = NewStepResult(sra.Chunk, Chunks(sra.Continuation))
+			} else if sra.HasValue {
+				srb = NewStepResult([]A{sra.Value}, Chunks(sra.Continuation))
+			} else {
+				srb = NewStepResultEmpty(Chunks(sra.Continuation))
+			}
+			return inheritFinalizer(srb, sra)
+		}))
+}
+
+// Unchunk converts a stream of slices to a stream of elements.
+// Each slice becomes a single chunk without copying.
+func Unchunk[A any](stm Stream[[]A]) Stream[A] {
+	return Stream[A](io.Map(
+		io.IO[StepResult[[]A]](stm),
+		func(sra StepResult[[]A]) (srb StepResult[A]) {
+			sra = uncons(sra)
+			if sra.IsFinished {
+				srb = NewStepResultFinished[A]()
+			} else if sra.HasValue {
+				srb = NewStepResultChunk(sra.Value, Unchunk(sra.Continuation))
+			} else {
+				srb = NewStepResultEmpty(Unchunk(sra.Continuation))
+			}
+			return inheritFinalizer(srb, sra)
+		}))
+}
+
+// MapChunks converts the stream chunk-by-chunk.
+// The function receives either an internal chunk or a single element wrapped in a slice.
+func MapChunks[A any, B any](stm Stream[A], f func([]A) []B) Stream[B] {
+	return Unchunk(Map(Chunks(stm), f))
+}
+
+// uncons converts a chunk step to a single value step.
+// The rest of the chunk is delivered in the continuation.
+// Other steps are returned as is.
+func uncons[A any](sra StepResult[A]) StepResult[A] {
+	if len(sra.Chunk) == 0 {
+		return sra
+	} else {
+		cont := sra.Continuation
+		if len(sra.Chunk) > 1 {
+			rest := inheritFinalizer(NewStepResultChunk(sra.Chunk[1:], cont), sra)
+			cont = FromStepResult(io.Lift(rest))
+		}
+		return inheritFinalizer(NewStepResult(sra.Chunk[0], cont), sra)
+	}
+}
@@ -0,0 +1,42 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+var chunked = stream.Unchunk(stream.LiftMany([]int{1, 2, 3}, []int{}, []int{4}, []int{5, 6, 7, 8, 9, 10}))
+
+func TestChunksUnchunk(t *testing.T) {
+	assert.Equal(t, nats10Values, UnsafeStreamToSlice(t, chunked))
+	assert.Equal(t, [][]int{{1, 2, 3}, {4}, {5, 6, 7, 8, 9, 10}}, UnsafeStreamToSlice(t, stream.Chunks(chunked)))
+	assert.Equal(t, [][]int{{1}, {2}}, UnsafeStreamToSlice(t, stream.Chunks(stream.Take(nats, 2))))
+}
+
+func TestChunkAwareOperations(t *testing.T) {
+	assert.Equal(t, []int{2, 4, 6}, UnsafeStreamToSlice(t, Mul2(stream.Take(chunked, 3))))
+	assert.Equal(t, []int{4, 5, 6}, UnsafeStreamToSlice(t, stream.Take(stream.Drop(chunked, 3), 3)))
+	assert.Equal(t, []int{2, 4, 6, 8, 10}, UnsafeStreamToSlice(t, stream.Filter(chunked, isEven)))
+	assert.Equal(t, 55, UnsafeIO(t, stream.Head(stream.Sum(chunked))))
+	assert.Equal(t, 3, UnsafeIO(t, stream.Head(stream.Drop(chunked, 2))))
+	assert.Equal(t, []int{1, 2}, UnsafeStreamToSlice(t, stream.TakeWhile(chunked, func(i int) bool { return i < 3 })))
+	flat := stream.FlatMap(chunked, func(i int) stream.Stream[int] { return stream.LiftMany(i, i) })
+	assert.Equal(t, []int{1, 1, 2, 2}, UnsafeStreamToSlice(t, stream.Take(flat, 4)))
+}
+
+func TestMapChunks(t *testing.T) {
+	lens := stream.MapChunks(chunked, func(as []int) []int { return []int{len(as)} })
+	assert.Equal(t, []int{3, 1, 6}, UnsafeStreamToSlice(t, lens))
+}
+
+func TestChunkFinalization(t *testing.T) {
+	count := 0
+	finalizer := io.FromPureEffect(func() { count += 1 })
+	assert.Equal(t, 1, UnsafeIO(t, stream.Head(stream.OnFinalize(chunked, finalizer))))
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []int{1, 2}, UnsafeStreamToSlice(t, stream.Take(stream.OnFinalize(chunked, finalizer), 2)))
+	assert.Equal(t, 2, count)
+}
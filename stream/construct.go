@@ -3,6 +3,7 @@ package stream
 import (
 	"github.com/primetalk/goio/fun"
 	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/option"
 )
 
 // Empty returns an empty stream.
@@ -51,11 +52,22 @@ func EmitMany[A any](as ...A) Stream[A] {
 }
 
 // FromSlice constructs a stream from the slice.
+// All elements are delivered in a single chunk.
+// NB! The slice is not copied and should not be modified afterwards.
 func FromSlice[A any](as []A) Stream[A] {
 	if len(as) == 0 {
 		return Empty[A]()
 	} else {
-		return Stream[A](io.Lift(NewStepResult(as[0], FromSlice(as[1:]))))
+		return Stream[A](io.Lift(NewStepResultChunk(as, Empty[A]())))
+	}
+}
+
+// FromOption returns a stream of one element if the option is defined.
+func FromOption[A any](oa option.Option[A]) Stream[A] {
+	if option.IsDefined(oa) {
+		return Lift(option.Get(oa))
+	} else {
+		return Empty[A]()
 	}
 }
 
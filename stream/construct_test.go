@@ -3,6 +3,7 @@ package stream_test
 import (
 	"testing"
 
+	"github.com/primetalk/goio/option"
 	"github.com/primetalk/goio/stream"
 	"github.com/stretchr/testify/assert"
 )
@@ -33,3 +34,8 @@ func TestFibs(t *testing.T) {
 	hIO := stream.Head(stream.Drop(fibs01, 55))
 	assert.Equal(t, int64(225851433717), UnsafeIO(t, hIO))
 }
+
+func TestFromOption(t *testing.T) {
+	assert.Equal(t, []int{1}, UnsafeStreamToSlice(t, stream.FromOption(option.Some(1))))
+	assert.Empty(t, UnsafeStreamToSlice(t, stream.FromOption(option.None[int]())))
+}
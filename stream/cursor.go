@@ -0,0 +1,141 @@
+package stream
+
+import (
+	"errors"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/option"
+	"github.com/primetalk/goio/resource"
+)
+
+// ErrCursorClosed is returned when reading from a closed cursor.
+var ErrCursorClosed = errors.New("cursor is closed")
+
+// Cursor allows to consume a stream incrementally from imperative code.
+// Cursor is not safe for concurrent use.
+type Cursor[A any] interface {
+	// Next returns the next element of the stream or None when the stream has finished.
+	Next() io.IO[option.Option[A]]
+	// NextN returns up to n next elements. Fewer elements are returned at the end of the stream.
+	NextN(n int) io.IO[[]A]
+	// Peek returns the next element without consuming it.
+	Peek() io.IO[option.Option[A]]
+	// Close finalizes the rest of the stream. Subsequent reads fail with ErrCursorClosed.
+	Close() io.IOUnit
+}
+
+type cursorImpl[A any] struct {
+	rest     Stream[A]
+	fin      io.IOUnit // finalizer of the rest
+	buffer   []A       // elements that have been evaluated but not consumed yet
+	finished bool
+	closed   bool
+}
+
+// NewCursor creates a cursor over the stream.
+// The cursor should be closed if the stream is not consumed until the end.
+func NewCursor[A any](stm Stream[A]) io.IO[Cursor[A]] {
+	return io.Pure(func() Cursor[A] {
+		return &cursorImpl[A]{rest: stm}
+	})
+}
+
+// ToCursor returns a cursor over the stream as a resource.
+// When the resource is released, the rest of the stream is finalized.
+func ToCursor[A any](stm Stream[A]) resource.Resource[Cursor[A]] {
+	return resource.NewResource(NewCursor(stm), func(c Cursor[A]) io.IOUnit {
+		return c.Close()
+	})
+}
+
+func (c *cursorImpl[A]) Next() io.IO[option.Option[A]] {
+	return io.Map(c.Peek(), func(oa option.Option[A]) option.Option[A] {
+		if option.IsDefined(oa) {
+			c.buffer = c.buffer[1:]
+		}
+		return oa
+	})
+}
+
+func (c *cursorImpl[A]) NextN(n int) io.IO[[]A] {
+	return c.nextN(n, []A{})
+}
+
+func (c *cursorImpl[A]) nextN(n int, start []A) io.IO[[]A] {
+	if len(start) >= n {
+		return io.Lift(start)
+	}
+	return io.FlatMap(c.fill(), func(available bool) io.IO[[]A] {
+		if available {
+			cnt := n - len(start)
+			if cnt > len(c.buffer) {
+				cnt = len(c.buffer)
+			}
+			res := append(start, c.buffer[:cnt]...)
+			c.buffer = c.buffer[cnt:]
+			return c.nextN(n, res)
+		} else {
+			return io.Lift(start)
+		}
+	})
+}
+
+func (c *cursorImpl[A]) Peek() io.IO[option.Option[A]] {
+	return io.Map(c.fill(), func(available bool) option.Option[A] {
+		if available {
+			return option.Some(c.buffer[0])
+		} else {
+			return option.None[A]()
+		}
+	})
+}
+
+func (c *cursorImpl[A]) Close() io.IOUnit {
+	return io.Delay(func() io.IOUnit {
+		fin := c.fin
+		wasActive := !c.closed && !c.finished
+		c.closed = true
+		c.buffer = nil
+		if wasActive && fin != nil {
+			return fin
+		} else {
+			return io.IOUnit1
+		}
+	})
+}
+
+// fill evaluates the stream until there is at least one element in the buffer.
+// Returns false when the stream has finished.
+func (c *cursorImpl[A]) fill() io.IO[bool] {
+	return io.Delay(func() io.IO[bool] {
+		if c.closed {
+			return io.Fail[bool](ErrCursorClosed)
+		} else if len(c.buffer) > 0 {
+			return io.Lift(true)
+		} else if c.finished {
+			return io.Lift(false)
+		} else {
+			return io.Fold(
+				io.IO[StepResult[A]](c.rest),
+				func(sra StepResult[A]) io.IO[bool] {
+					if sra.IsFinished {
+						c.finished = true
+						return io.Lift(false)
+					}
+					c.rest = sra.Continuation
+					c.fin = sra.Finalizer
+					if len(sra.Chunk) > 0 {
+						c.buffer = append(c.buffer, sra.Chunk...)
+					} else if sra.HasValue {
+						c.buffer = append(c.buffer, sra.Value)
+					}
+					return c.fill()
+				},
+				func(err error) io.IO[bool] {
+					c.finished = true
+					return io.Fail[bool](err)
+				},
+			)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/option"
+	"github.com/primetalk/goio/resource"
+	"github.com/primetalk/goio/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	cursor := UnsafeIO(t, stream.NewCursor(chunked))
+	assert.Equal(t, option.Some(1), UnsafeIO(t, cursor.Peek()))
+	assert.Equal(t, option.Some(1), UnsafeIO(t, cursor.Next()))
+	assert.Equal(t, []int{2, 3, 4, 5}, UnsafeIO(t, cursor.NextN(4)))
+	assert.Equal(t, option.Some(6), UnsafeIO(t, cursor.Peek()))
+	assert.Equal(t, []int{6, 7, 8, 9, 10}, UnsafeIO(t, cursor.NextN(100)))
+	assert.Equal(t, option.None[int](), UnsafeIO(t, cursor.Next()))
+	assert.Empty(t, UnsafeIO(t, cursor.NextN(1)))
+	UnsafeIO(t, cursor.Close())
+	UnsafeIOExpectError(t, stream.ErrCursorClosed, cursor.Next())
+}
+
+func TestCursorFailure(t *testing.T) {
+	cursor := UnsafeIO(t, stream.NewCursor(natsAndThenFail))
+	assert.Equal(t, nats10Values, UnsafeIO(t, cursor.NextN(10)))
+	UnsafeIOExpectError(t, errExpected, cursor.Next())
+}
+
+func TestCursorResource(t *testing.T) {
+	count := 0
+	finalizer := io.FromPureEffect(func() { count += 1 })
+	first3 := resource.Use(stream.ToCursor(stream.OnFinalize(nats, finalizer)), func(c stream.Cursor[int]) io.IO[[]int] {
+		return c.NextN(3)
+	})
+	assert.Equal(t, []int{1, 2, 3}, UnsafeIO(t, first3))
+	assert.Equal(t, 1, count)
+}
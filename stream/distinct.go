@@ -0,0 +1,134 @@
+package stream
+
+import (
+	"container/list"
+	"time"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+)
+
+// Distinct returns only unique elements.
+// NB! All elements are kept in memory. For infinite streams consider DedupWithin.
+func Distinct[A comparable](stm Stream[A]) Stream[A] {
+	return DistinctBy(stm, fun.Identity[A])
+}
+
+// DistinctBy returns only elements with unique keys. The first element with the key wins.
+// NB! All keys are kept in memory. For infinite streams consider DedupWithin.
+func DistinctBy[A any, K comparable](stm Stream[A], key func(A) K) Stream[A] {
+	return Stream[A](io.Delay(func() io.IO[StepResult[A]] {
+		seen := map[K]struct{}{}
+		return io.IO[StepResult[A]](Filter(stm, func(a A) bool {
+			k := key(a)
+			_, isPresent := seen[k]
+			if !isPresent {
+				seen[k] = struct{}{}
+			}
+			return !isPresent
+		}))
+	}))
+}
+
+// DistinctConsecutive removes consecutive duplicates.
+// Only changes are left in the stream.
+func DistinctConsecutive[A comparable](stm Stream[A]) Stream[A] {
+	return StateFlatMap(stm, fun.Pair[bool, A]{},
+		func(a A, prev fun.Pair[bool, A]) io.IO[fun.Pair[fun.Pair[bool, A], Stream[A]]] {
+			return io.Pure(func() fun.Pair[fun.Pair[bool, A], Stream[A]] {
+				if prev.V1 && prev.V2 == a {
+					return fun.NewPair(prev, Empty[A]())
+				} else {
+					return fun.NewPair(fun.NewPair(true, a), Lift(a))
+				}
+			})
+		})
+}
+
+// DedupWithin drops elements whose key has been seen during the given time window.
+// The window starts when the key is seen for the first time.
+// Keys are forgotten after the window expires, so the memory is bounded by
+// the number of distinct keys that arrive during the window.
+func DedupWithin[A any, K comparable](stm Stream[A], key func(A) K, window time.Duration) Stream[A] {
+	return Stream[A](io.Delay(func() io.IO[StepResult[A]] {
+		seen := newExpiringSet[K](window)
+		return io.IO[StepResult[A]](Filter(stm, func(a A) bool {
+			return seen.add(key(a), time.Now())
+		}))
+	}))
+}
+
+// DedupWithinLast drops elements whose key is among the last size distinct keys.
+// It uses an LRU set, so the memory is bounded by size.
+// A duplicate refreshes the key.
+func DedupWithinLast[A any, K comparable](stm Stream[A], key func(A) K, size int) Stream[A] {
+	return Stream[A](io.Delay(func() io.IO[StepResult[A]] {
+		seen := newLRUSet[K](size)
+		return io.IO[StepResult[A]](Filter(stm, func(a A) bool {
+			return seen.add(key(a))
+		}))
+	}))
+}
+
+// lruSet is a set of limited size. The least recently used key is evicted first.
+type lruSet[K comparable] struct {
+	size  int
+	order *list.List // front is the most recently used
+	keys  map[K]*list.Element
+}
+
+func newLRUSet[K comparable](size int) *lruSet[K] {
+	return &lruSet[K]{
+		size:  size,
+		order: list.New(),
+		keys:  map[K]*list.Element{},
+	}
+}
+
+// add returns true if the key is new.
+func (s *lruSet[K]) add(k K) bool {
+	if e, ok := s.keys[k]; ok {
+		s.order.MoveToFront(e)
+		return false
+	}
+	s.keys[k] = s.order.PushFront(k)
+	if s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.keys, oldest.Value.(K))
+	}
+	return true
+}
+
+// expiringSet is a set where each key is kept for the given duration.
+type expiringSet[K comparable] struct {
+	window time.Duration
+	order  *list.List // keys in order of addition
+	keys   map[K]time.Time
+}
+
+func newExpiringSet[K comparable](window time.Duration) *expiringSet[K] {
+	return &expiringSet[K]{
+		window: window,
+		order:  list.New(),
+		keys:   map[K]time.Time{},
+	}
+}
+
+// add returns true if the key is new or has expired.
+func (s *expiringSet[K]) add(k K, now time.Time) bool {
+	for e := s.order.Front(); e != nil; e = s.order.Front() {
+		k1 := e.Value.(K)
+		if now.Sub(s.keys[k1]) < s.window {
+			break
+		}
+		s.order.Remove(e)
+		delete(s.keys, k1)
+	}
+	if _, ok := s.keys[k]; ok {
+		return false
+	}
+	s.keys[k] = now
+	s.order.PushBack(k)
+	return true
+}
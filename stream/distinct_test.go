@@ -0,0 +1,39 @@
+package stream_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+var withDuplicates = stream.LiftMany(1, 1, 2, 3, 2, 2, 1, 4)
+
+func TestDistinct(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3, 4}, UnsafeStreamToSlice(t, stream.Distinct(withDuplicates)))
+	// each evaluation starts from scratch
+	assert.Equal(t, []int{1, 2, 3, 4}, UnsafeStreamToSlice(t, stream.Distinct(withDuplicates)))
+	assert.Equal(t, []int{1, 2}, UnsafeStreamToSlice(t, stream.DistinctBy(withDuplicates, isEven)))
+}
+
+func TestDistinctConsecutive(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3, 2, 1, 4}, UnsafeStreamToSlice(t, stream.DistinctConsecutive(withDuplicates)))
+}
+
+func TestDedupWithinLast(t *testing.T) {
+	deduped := stream.DedupWithinLast(withDuplicates, func(i int) int { return i }, 2)
+	assert.Equal(t, []int{1, 2, 3, 1, 4}, UnsafeStreamToSlice(t, deduped))
+}
+
+func TestDedupWithin(t *testing.T) {
+	// Duplicates arrive 10-20ms after the first 1, while the second 1 arrives after the window of 100ms has expired.
+	delays := []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond, 300 * time.Millisecond, 10 * time.Millisecond}
+	slow := stream.MapEval(stream.ZipWithIndex(stream.LiftMany(1, 1, 2, 1, 1)), func(p fun.Pair[int, int]) io.IO[int] {
+		return io.SleepA(delays[p.V1], p.V2)
+	})
+	deduped := stream.DedupWithin(slow, func(i int) int { return i }, 100*time.Millisecond)
+	assert.Equal(t, []int{1, 2, 1}, UnsafeStreamToSlice(t, deduped))
+}
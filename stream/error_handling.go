@@ -0,0 +1,69 @@
+package stream
+
+import (
+	"github.com/primetalk/goio/either"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/option"
+)
+
+// HandleErrorWith continues with the stream returned by the handler
+// when the original stream fails.
+func HandleErrorWith[A any](stm Stream[A], handler func(err error) Stream[A]) Stream[A] {
+	return Stream[A](io.Fold(
+		io.IO[StepResult[A]](stm),
+		func(sra StepResult[A]) io.IO[StepResult[A]] {
+			if !sra.IsFinished {
+				sra.Continuation = HandleErrorWith(sra.Continuation, handler)
+			}
+			return io.Lift(sra)
+		},
+		func(err error) io.IO[StepResult[A]] {
+			return io.IO[StepResult[A]](handler(err))
+		},
+	))
+}
+
+// Attempt converts a stream into a stream of eithers.
+// All elements are Right, except probably the last one which is Left
+// and contains the error of the original stream.
+func Attempt[A any](stm Stream[A]) Stream[either.Either[error, A]] {
+	return HandleErrorWith(
+		Map(stm, either.Right[error, A]),
+		func(err error) Stream[either.Either[error, A]] {
+			return Lift(either.Left[error, A](err))
+		},
+	)
+}
+
+// OnComplete appends the stream returned by onComplete after the original stream
+// regardless of whether it finished successfully or failed.
+// In case of failure the error is raised again after the appended stream.
+func OnComplete[A any](stm Stream[A], onComplete func() Stream[A]) Stream[A] {
+	return AndThenLazy(
+		HandleErrorWith(stm, func(err error) Stream[A] {
+			return AndThenLazy(onComplete(), func() Stream[A] {
+				return Fail[A](err)
+			})
+		}),
+		onComplete,
+	)
+}
+
+// RetryStream restarts the stream from the very beginning when it fails.
+// The strategy decides whether another attempt should be made
+// and might introduce a delay before it (backoff).
+// NB! Elements that were emitted before failure will be emitted again.
+func RetryStream[A any, S any](stm Stream[A], strategy func(s S, err error) io.IO[option.Option[S]], zero S) Stream[A] {
+	return HandleErrorWith(stm, func(err error) Stream[A] {
+		return Stream[A](io.FlatMap(strategy(zero, err), func(os option.Option[S]) io.IO[StepResult[A]] {
+			return option.Match(os,
+				func(s S) io.IO[StepResult[A]] {
+					return io.IO[StepResult[A]](RetryStream(stm, strategy, s))
+				},
+				func() io.IO[StepResult[A]] {
+					return io.Fail[StepResult[A]](err)
+				},
+			)
+		}))
+	})
+}
@@ -0,0 +1,98 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/primetalk/goio/either"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleErrorWith(t *testing.T) {
+	recovered := stream.HandleErrorWith(natsAndThenFail, func(err error) stream.Stream[int] {
+		assert.Equal(t, errExpected, err)
+		return stream.Lift(-1)
+	})
+	assert.Equal(t, append(nats10Values, -1), UnsafeStreamToSlice(t, recovered))
+}
+
+func TestAttempt(t *testing.T) {
+	attempts := UnsafeStreamToSlice(t, stream.Attempt(stream.AndThen(stream.Lift(1), failedStream)))
+	assert.Equal(t, []either.Either[error, int]{
+		either.Right[error](1),
+		either.Left[error, int](errExpected),
+	}, attempts)
+}
+
+func TestOnComplete(t *testing.T) {
+	completed := stream.OnComplete(nats10, func() stream.Stream[int] { return stream.Lift(0) })
+	assert.Equal(t, append(nats10Values, 0), UnsafeStreamToSlice(t, completed))
+
+	results := []int{}
+	failed := stream.OnComplete(natsAndThenFail, func() stream.Stream[int] { return stream.Lift(0) })
+	UnsafeIOExpectError(t, errExpected, stream.ForEach(failed, func(i int) {
+		results = append(results, i)
+	}))
+	assert.Equal(t, append(nats10Values, 0), results)
+}
+
+func TestOnFinalize(t *testing.T) {
+	count := 0
+	finalizer := io.FromPureEffect(func() { count += 1 })
+
+	UnsafeStreamToSlice(t, stream.OnFinalize(nats10, finalizer))
+	assert.Equal(t, 1, count)
+
+	UnsafeIOExpectError(t, errExpected, stream.DrainAll(stream.OnFinalize(natsAndThenFail, finalizer)))
+	assert.Equal(t, 2, count)
+
+	taken := stream.Take(stream.Map(stream.OnFinalize(nats, finalizer), isEven), 3)
+	assert.Equal(t, []bool{false, true, false}, UnsafeStreamToSlice(t, taken))
+	assert.Equal(t, 3, count)
+
+	assert.Equal(t, 1, UnsafeIO(t, stream.Head(stream.OnFinalize(nats, finalizer))))
+	assert.Equal(t, 4, count)
+
+	mapFailed := stream.MapEval(stream.OnFinalize(nats, finalizer), func(i int) io.IO[int] {
+		return io.Fail[int](errExpected)
+	})
+	UnsafeIOExpectError(t, errExpected, stream.DrainAll(mapFailed))
+	assert.Equal(t, 5, count)
+}
+
+func TestOnFinalizeInFlatMap(t *testing.T) {
+	finalized := []int{}
+	finalize := func(i int) io.IOUnit {
+		return io.FromPureEffect(func() { finalized = append(finalized, i) })
+	}
+	outer := stream.OnFinalize(stream.LiftMany(1, 2, 3), finalize(0))
+	flat := stream.FlatMap(outer, func(i int) stream.Stream[int] {
+		return stream.OnFinalize(stream.LiftMany(i, i), finalize(i))
+	})
+	assert.Equal(t, []int{1, 1, 2}, UnsafeStreamToSlice(t, stream.Take(flat, 3)))
+	assert.Equal(t, []int{1, 2, 0}, finalized)
+}
+
+func TestRetryStream(t *testing.T) {
+	attempt := 0
+	flaky := stream.FlatMap(
+		stream.Eval(io.Pure(func() int {
+			attempt += 1
+			return attempt
+		})),
+		func(a int) stream.Stream[int] {
+			if a < 3 {
+				return stream.AndThen(stream.Lift(-a), failedStream)
+			} else {
+				return stream.Lift(a)
+			}
+		},
+	)
+	retried := stream.RetryStream(flaky, io.RetryStrategyMaxCount("expected"), 2)
+	assert.Equal(t, []int{-1, -2, 3}, UnsafeStreamToSlice(t, retried))
+
+	attempt = 0
+	exhausted := stream.RetryStream(flaky, io.RetryStrategyMaxCount("expected"), 1)
+	UnsafeIOExpectError(t, errExpected, stream.DrainAll(exhausted))
+}
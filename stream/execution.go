@@ -13,19 +13,29 @@ import (
 type Collector[A any, B any] func(Stream[A]) io.IO[B]
 
 // Collect collects all element from the stream and for each element invokes
-// the provided function
+// the provided function.
+// If the collector fails, the rest of the stream is finalized.
 func Collect[A any](stm Stream[A], collector func(A) error) io.IO[fun.Unit] {
 	return io.FlatMap(
 		io.IO[StepResult[A]](stm),
 		func(sra StepResult[A]) io.IO[fun.Unit] {
+			sra = uncons(sra)
 			if sra.IsFinished {
 				return io.Lift(fun.Unit1)
 			} else {
 				rest := Collect(sra.Continuation, collector)
 				if sra.HasValue {
-					return io.AndThen(io.FromUnit(func() error {
-						return collector(sra.Value)
-					}), rest)
+					return io.Fold(
+						io.FromUnit(func() error {
+							return collector(sra.Value)
+						}),
+						func(fun.Unit) io.IO[fun.Unit] {
+							return rest
+						},
+						func(err error) io.IO[fun.Unit] {
+							return finalizeAndFail[A, fun.Unit](sra, err)
+						},
+					)
 				} else {
 					return rest
 				}
@@ -61,6 +71,8 @@ func AppendToSlice[A any](stm Stream[A], start []A) io.IO[[]A] {
 		func(sra StepResult[A]) io.IO[[]A] {
 			if sra.IsFinished {
 				return io.Lift(start)
+			} else if len(sra.Chunk) > 0 {
+				return AppendToSlice(sra.Continuation, append(start, sra.Chunk...))
 			} else if sra.HasValue {
 				return AppendToSlice(sra.Continuation, append(start, sra.Value))
 			} else {
@@ -83,6 +95,8 @@ var ErrHeadOfEmptyStream = errors.New("head of empty stream")
 var ErrLastOfEmptyStream = errors.New("last of empty stream")
 
 // HeadAndTail returns the very first element of the stream and the rest of the stream.
+// The caller becomes responsible for the tail. If the tail is not evaluated
+// to the end, it won't be finalized.
 func HeadAndTail[A any](stm Stream[A]) io.IO[fun.Pair[A, Stream[A]]] {
 	return StreamMatch(stm,
 		func() io.IO[fun.Pair[A, Stream[A]]] {
@@ -163,6 +177,7 @@ func Partition[A any, C any, D any](stm Stream[A],
 // TakeAndTail collects n leading elements of the stream and
 // returns them along with the tail of the stream.
 // If the stream is shorter, then only available elements are returned and an emtpy stream.
+// Like in HeadAndTail, the caller becomes responsible for the tail.
 func TakeAndTail[A any](stm Stream[A], n int, prefix []A) io.IO[fun.Pair[[]A, Stream[A]]] {
 	if n == 0 {
 		return io.Lift(fun.NewPair(prefix, stm))
@@ -0,0 +1,104 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/primetalk/goio/io"
+)
+
+// OnFinalize runs the finalizer when the stream is no longer needed.
+// It happens when the stream finishes, fails or is abandoned by a downstream
+// operation like Take.
+// The finalizer is executed at most once per stream evaluation.
+func OnFinalize[A any](stm Stream[A], finalizer io.IOUnit) Stream[A] {
+	return Stream[A](io.Delay(func() io.IO[StepResult[A]] {
+		return io.IO[StepResult[A]](attachFinalizer(stm, once(finalizer), true))
+	}))
+}
+
+// attachFinalizer adds the given finalizer to every step of the stream.
+// When the stream fails, the finalizer is executed.
+// When the stream finishes, the finalizer is executed only if runOnFinish is true.
+// Otherwise it's expected that someone else will take care of it.
+func attachFinalizer[A any](stm Stream[A], fin io.IOUnit, runOnFinish bool) Stream[A] {
+	if fin == nil {
+		return stm
+	}
+	return Stream[A](io.Fold(
+		io.IO[StepResult[A]](stm),
+		func(sra StepResult[A]) io.IO[StepResult[A]] {
+			if sra.IsFinished {
+				if runOnFinish {
+					return io.AndThen(fin, io.Lift(sra))
+				} else {
+					return io.Lift(sra)
+				}
+			} else {
+				sra.Continuation = attachFinalizer(sra.Continuation, fin, runOnFinish)
+				sra.Finalizer = combineFinalizers(sra.Finalizer, fin)
+				return io.Lift(sra)
+			}
+		},
+		func(err error) io.IO[StepResult[A]] {
+			return io.Finally(io.Fail[StepResult[A]](err), fin)
+		},
+	))
+}
+
+// combineFinalizers returns a finalizer that executes both finalizers in order.
+// The second one is executed even if the first one fails.
+func combineFinalizers(first io.IOUnit, second io.IOUnit) io.IOUnit {
+	if first == nil {
+		return second
+	} else if second == nil {
+		return first
+	} else {
+		return io.Finally(first, second)
+	}
+}
+
+// inheritFinalizer copies the finalizer of the original step to the new one.
+func inheritFinalizer[A any, B any](srb StepResult[B], sra StepResult[A]) StepResult[B] {
+	srb.Finalizer = sra.Finalizer
+	return srb
+}
+
+// finalizer returns the finalizer of the step result or a no-op.
+func finalizer[A any](sra StepResult[A]) io.IOUnit {
+	if sra.Finalizer == nil {
+		return io.IOUnit1
+	} else {
+		return sra.Finalizer
+	}
+}
+
+// abandon returns an empty stream that releases the continuation of the step result.
+func abandon[A any](sra StepResult[A]) Stream[A] {
+	if sra.Finalizer == nil {
+		return Empty[A]()
+	} else {
+		return EvalEmpty[A](sra.Finalizer)
+	}
+}
+
+// finalizeAndFail runs the finalizer of the step result and then fails with the given error.
+func finalizeAndFail[A any, B any](sra StepResult[A], err error) io.IO[B] {
+	return io.Finally(io.Fail[B](err), finalizer(sra))
+}
+
+// once returns an IO that runs the given one only the first time it's executed.
+func once(iou io.IOUnit) io.IOUnit {
+	var mu sync.Mutex
+	done := false
+	return io.Delay(func() io.IOUnit {
+		mu.Lock()
+		shouldRun := !done
+		done = true
+		mu.Unlock()
+		if shouldRun {
+			return iou
+		} else {
+			return io.IOUnit1
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/option"
+)
+
+// GroupByKeyBufferSize is the number of elements that are buffered for each group.
+const GroupByKeyBufferSize = 16
+
+// GroupByKey routes elements into per-key substreams.
+// Unlike GroupBy, elements with the same key don't need to be adjacent.
+// Whenever a key is encountered that doesn't have an open group,
+// a new group (key and substream) is emitted.
+// Substreams should be consumed concurrently (e.g. with Balance or ThroughExecutionContextUnordered),
+// because a full group buffer blocks the routing of other elements.
+// At most maxGroups groups are open (unbounded when maxGroups <= 0). When the limit is reached,
+// the least recently active group is completed to make room for the new one.
+// A group that hasn't received elements during idleTimeout is completed (never when idleTimeout <= 0).
+// A key of a completed group (as well as a group that was abandoned by it's consumer)
+// starts a new group.
+// When the original stream fails, the result and all substreams fail.
+// When the result is abandoned, the rest of the original stream is finalized and all substreams complete.
+func GroupByKey[A any, K comparable](stm Stream[A], key func(A) K, maxGroups int, idleTimeout time.Duration) Stream[fun.Pair[K, Stream[A]]] {
+	return Stream[fun.Pair[K, Stream[A]]](io.Delay(func() io.IO[StepResult[fun.Pair[K, Stream[A]]]] {
+		r := &groupRouter[A, K]{
+			key:         key,
+			maxGroups:   maxGroups,
+			idleTimeout: idleTimeout,
+			groups:      map[K]*keyGroup[A]{},
+			outer:       make(chan StreamEvent[fun.Pair[K, Stream[A]]]),
+			stop:        make(chan struct{}),
+		}
+		stopIO := io.FromPureEffect(func() {
+			r.stopOnce.Do(func() { close(r.stop) })
+		})
+		return io.AndThen(
+			io.FireAndForget(io.FromPureEffect(func() { r.run(stm) })),
+			io.IO[StepResult[fun.Pair[K, Stream[A]]]](OnFinalize(FromStreamEventChannel(r.outer), stopIO)),
+		)
+	}))
+}
+
+// keyGroup is an open group of GroupByKey.
+type keyGroup[A any] struct {
+	*subscriber[A]
+	lastActive time.Time     // protected by the router's lock
+	closing    chan struct{} // closed when the group is being completed
+	sendMu     sync.Mutex    // held while an element is being sent to ch
+}
+
+// groupRouter distributes elements among groups in a separate go routine.
+type groupRouter[A any, K comparable] struct {
+	key         func(A) K
+	maxGroups   int
+	idleTimeout time.Duration
+	mu          sync.Mutex // protects groups
+	groups      map[K]*keyGroup[A]
+	outer       chan StreamEvent[fun.Pair[K, Stream[A]]]
+	stop        chan struct{} // closed when the result stream is finalized
+	stopOnce    sync.Once
+}
+
+// run routes all elements of the stream until it finishes or the router is stopped.
+func (r *groupRouter[A, K]) run(stm Stream[A]) {
+	cursor, _ := io.UnsafeRunSync(NewCursor(stm))
+	defer io.UnsafeRunSync(cursor.Close())
+	if r.idleTimeout > 0 {
+		finished := make(chan struct{})
+		defer close(finished)
+		go r.closeIdleGroups(finished)
+	}
+	for {
+		select {
+		case <-r.stop:
+			r.closeAll(NewStreamEventFinished[A]())
+			return
+		default:
+		}
+		oa, err := io.UnsafeRunSync(cursor.Next())
+		if err != nil {
+			r.closeAll(NewStreamEventError[A](err))
+			r.sendOuter(NewStreamEventError[fun.Pair[K, Stream[A]]](err))
+			return
+		} else if option.IsEmpty(oa) {
+			r.closeAll(NewStreamEventFinished[A]())
+			close(r.outer)
+			return
+		} else if !r.route(option.Get(oa)) {
+			r.closeAll(NewStreamEventFinished[A]())
+			return
+		}
+	}
+}
+
+// route sends the element to it's group. Returns false when the router has been stopped.
+func (r *groupRouter[A, K]) route(a A) bool {
+	k := r.key(a)
+	for {
+		g, isNew := r.groupFor(k)
+		if isNew && !r.sendOuter(NewStreamEvent(fun.NewPair(k, g.stream()))) {
+			return false
+		}
+		sent, stopped := r.send(k, g, a)
+		if stopped {
+			return false
+		} else if sent {
+			return true
+		}
+		// the group has been closed or it's consumer has left, the element goes to a new group.
+	}
+}
+
+// groupFor returns an open group for the key. A new group is created if needed.
+func (r *groupRouter[A, K]) groupFor(k K) (g *keyGroup[A], isNew bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.groups[k]
+	if ok && g.hasLeft() {
+		r.closeGroup(k, g)
+		ok = false
+	}
+	if !ok {
+		if r.maxGroups > 0 && len(r.groups) >= r.maxGroups {
+			r.closeLeastRecentlyActive()
+		}
+		g = &keyGroup[A]{
+			subscriber: &subscriber[A]{
+				ch:   make(chan StreamEvent[A], GroupByKeyBufferSize),
+				done: make(chan struct{}),
+			},
+			lastActive: time.Now(),
+			closing:    make(chan struct{}),
+		}
+		r.groups[k] = g
+		isNew = true
+	}
+	return
+}
+
+// send delivers the element to the group if it's still open.
+// The router's lock is not held during the blocking send, so that a slow consumer
+// doesn't prevent completion of other groups. The group's channel is not closed
+// concurrently, because closeGroup waits for sendMu.
+func (r *groupRouter[A, K]) send(k K, g *keyGroup[A], a A) (sent bool, stopped bool) {
+	r.mu.Lock()
+	isOpen := r.groups[k] == g
+	if isOpen {
+		g.lastActive = time.Now()
+	}
+	r.mu.Unlock()
+	if !isOpen {
+		return
+	}
+	g.sendMu.Lock()
+	defer g.sendMu.Unlock()
+	select {
+	case g.ch <- NewStreamEvent(a):
+		sent = true
+	case <-g.done:
+	case <-g.closing:
+	case <-r.stop:
+		stopped = true
+	}
+	return
+}
+
+// sendOuter delivers the event to the result stream. Returns false when the router has been stopped.
+func (r *groupRouter[A, K]) sendOuter(e StreamEvent[fun.Pair[K, Stream[A]]]) bool {
+	select {
+	case r.outer <- e:
+		return true
+	case <-r.stop:
+		return false
+	}
+}
+
+// closeIdleGroups periodically completes groups that haven't been active during idleTimeout.
+func (r *groupRouter[A, K]) closeIdleGroups(finished chan struct{}) {
+	ticker := time.NewTicker(r.idleTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-finished:
+			return
+		case now := <-ticker.C:
+			r.mu.Lock()
+			for k, g := range r.groups {
+				if now.Sub(g.lastActive) >= r.idleTimeout {
+					r.closeGroup(k, g)
+				}
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// closeLeastRecentlyActive completes the group that has been inactive for the longest time.
+// Should be called under lock.
+func (r *groupRouter[A, K]) closeLeastRecentlyActive() {
+	var oldestKey K
+	var oldest *keyGroup[A]
+	for k, g := range r.groups {
+		if oldest == nil || g.lastActive.Before(oldest.lastActive) {
+			oldestKey, oldest = k, g
+		}
+	}
+	if oldest != nil {
+		r.closeGroup(oldestKey, oldest)
+	}
+}
+
+// closeGroup completes the substream. Should be called under lock.
+// A concurrent send to the group is interrupted first.
+func (r *groupRouter[A, K]) closeGroup(k K, g *keyGroup[A]) {
+	delete(r.groups, k)
+	close(g.closing)
+	g.sendMu.Lock()
+	defer g.sendMu.Unlock()
+	close(g.ch)
+}
+
+// closeAll sends the last event to all groups and closes them.
+func (r *groupRouter[A, K]) closeAll(last StreamEvent[A]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, g := range r.groups {
+		if last.Error != nil {
+			g.sendLast(last)
+		}
+		r.closeGroup(k, g)
+	}
+}
+
+// hasLeft checks whether the consumer has abandoned the substream.
+func (s *subscriber[A]) hasLeft() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// stream returns the stream of the subscriber's events.
+// When the stream is finalized, the subscriber leaves.
+func (s *subscriber[A]) stream() Stream[A] {
+	return OnFinalize(FromStreamEventChannel(s.ch), io.FromPureEffect(func() {
+		s.leave.Do(func() { close(s.done) })
+	}))
+}
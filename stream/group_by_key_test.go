@@ -0,0 +1,125 @@
+package stream_test
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/slice"
+	"github.com/primetalk/goio/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+// collectGroups consumes all groups concurrently and returns their contents.
+func collectGroups(t *testing.T, groups stream.Stream[fun.Pair[int, stream.Stream[int]]]) (res []fun.Pair[int, []int]) {
+	var mu sync.Mutex
+	fibers := UnsafeStreamToSlice(t, stream.MapEval(groups, func(p fun.Pair[int, stream.Stream[int]]) io.IO[io.Fiber[fun.Unit]] {
+		return io.Start(io.FlatMap(stream.ToSlice(p.V2), func(as []int) io.IOUnit {
+			return io.FromPureEffect(func() {
+				mu.Lock()
+				defer mu.Unlock()
+				res = append(res, fun.NewPair(p.V1, as))
+			})
+		}))
+	}))
+	for _, f := range fibers {
+		UnsafeIO(t, f.Join())
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].V1 < res[j].V1 || (res[i].V1 == res[j].V1 && res[i].V2[0] < res[j].V2[0])
+	})
+	return
+}
+
+func mod3(i int) int { return i % 3 }
+
+func TestGroupByKey(t *testing.T) {
+	groups := stream.GroupByKey(stream.Take(nats, 100), mod3, 0, 0)
+	res := collectGroups(t, groups)
+	assert.Len(t, res, 3)
+	for _, p := range res {
+		if p.V1 == 1 {
+			assert.Len(t, p.V2, 34)
+		} else {
+			assert.Len(t, p.V2, 33)
+		}
+		for _, i := range p.V2 {
+			assert.Equal(t, p.V1, mod3(i))
+		}
+	}
+}
+
+func TestGroupByKeyMaxGroups(t *testing.T) {
+	res := collectGroups(t, stream.GroupByKey(nats10, mod3, 2, 0))
+	// groups are evicted in round-robin manner, hence each group has a single element.
+	assert.Len(t, res, 10)
+}
+
+func TestGroupByKeyIdleTimeout(t *testing.T) {
+	// The idle timeout is checked every 50ms, so an idle group is completed within 100-150ms.
+	// The first two elements are 10ms apart, while 1 is idle for 400ms before the last element.
+	delays := []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 200 * time.Millisecond, 200 * time.Millisecond}
+	slow := stream.MapEval(stream.ZipWithIndex(stream.LiftMany(1, 1, 2, 1)), func(p fun.Pair[int, int]) io.IO[int] {
+		return io.SleepA(delays[p.V1], p.V2)
+	})
+	res := collectGroups(t, stream.GroupByKey(slow, fun.Identity[int], 0, 100*time.Millisecond))
+	// 1 is idle while 2 is being produced.
+	assert.Equal(t, []fun.Pair[int, []int]{
+		fun.NewPair(1, []int{1, 1}),
+		fun.NewPair(1, []int{1}),
+		fun.NewPair(2, []int{2}),
+	}, res)
+}
+
+func TestGroupByKeyIdleTimeoutWithSlowGroup(t *testing.T) {
+	// The consumer of 1 doesn't read until 2 is completed by the idle timeout,
+	// while the router is blocked on the full buffer of 1.
+	ones := stream.FromSlice(slice.Map(slice.Range(0, 100), fun.Const[int](1)))
+	groups := stream.GroupByKey(stream.AndThen(stream.Lift(2), ones), fun.Identity[int], 0, 50*time.Millisecond)
+	group2Done := make(chan struct{})
+	var count int32
+	consume := stream.MapEval(groups, func(p fun.Pair[int, stream.Stream[int]]) io.IO[io.Fiber[fun.Unit]] {
+		if p.V1 == 2 {
+			return io.Start(io.AndThen(stream.DrainAll(p.V2), io.FromPureEffect(func() { close(group2Done) })))
+		}
+		return io.Start(io.AndThen(
+			io.FromPureEffect(func() { <-group2Done }),
+			stream.ForEach(p.V2, func(int) { atomic.AddInt32(&count, 1) }),
+		))
+	})
+	fibers := UnsafeIO(t, io.Start(stream.ToSlice(consume)))
+	select {
+	case <-group2Done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("idle group has not been completed")
+	}
+	for _, f := range UnsafeIO(t, fibers.Join()) {
+		UnsafeIO(t, f.Join())
+	}
+	assert.Equal(t, int32(100), atomic.LoadInt32(&count))
+}
+
+func TestGroupByKeyFailure(t *testing.T) {
+	groups := stream.GroupByKey(natsAndThenFail, mod3, 0, 0)
+	fibers := []io.Fiber[fun.Unit]{}
+	UnsafeIOExpectError(t, errExpected, stream.ForEach(groups, func(p fun.Pair[int, stream.Stream[int]]) {
+		fibers = append(fibers, UnsafeIO(t, io.Start(stream.DrainAll(p.V2))))
+	}))
+	assert.Len(t, fibers, 3)
+	for _, f := range fibers {
+		UnsafeIOExpectError(t, errExpected, f.Join())
+	}
+}
+
+func TestGroupByKeyAbandoned(t *testing.T) {
+	var count int32
+	finalizer := io.FromPureEffect(func() { atomic.AddInt32(&count, 1) })
+	groups := stream.GroupByKey(stream.OnFinalize(nats, finalizer), mod3, 0, 0)
+	first := UnsafeIO(t, stream.Head(groups))
+	assert.Equal(t, 1, first.V1)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&count) == 1 }, time.Second, time.Millisecond)
+}
@@ -0,0 +1,72 @@
+package stream
+
+import (
+	"time"
+
+	"github.com/primetalk/goio/io"
+)
+
+// Metrics receives measurements of instrumented stream stages.
+// Implementations should be safe to use from multiple go routines.
+type Metrics interface {
+	// Elements counts elements emitted by the stage.
+	Elements(stage string, n int)
+	// Error counts failures of the stage.
+	Error(stage string)
+	// UpstreamWait adds the time spent waiting for the upstream to produce the next step.
+	UpstreamWait(stage string, d time.Duration)
+	// DownstreamWait adds the time between delivering a step and the request of the next one.
+	DownstreamWait(stage string, d time.Duration)
+}
+
+// DefaultMetricsRegistry collects metrics of stages instrumented with Instrument.
+var DefaultMetricsRegistry = NewMetricsRegistry()
+
+// Instrument reports metrics of the stream to DefaultMetricsRegistry under the given stage name.
+// The stream itself is returned unchanged.
+// Large upstream wait means that the stages before this point are slow.
+// Large downstream wait means that the consumer is slow.
+func Instrument[A any](name string, stm Stream[A]) Stream[A] {
+	return InstrumentWith(DefaultMetricsRegistry, name, stm)
+}
+
+// InstrumentWith reports metrics of the stream to the given metrics under the stage name.
+func InstrumentWith[A any](metrics Metrics, name string, stm Stream[A]) Stream[A] {
+	return instrumentStep(metrics, name, stm, time.Time{})
+}
+
+// instrumentStep measures a single step. delivered is the time when the previous step
+// has been delivered downstream (zero for the first step).
+func instrumentStep[A any](metrics Metrics, name string, stm Stream[A], delivered time.Time) Stream[A] {
+	return Stream[A](io.Delay(func() io.IO[StepResult[A]] {
+		start := time.Now()
+		if !delivered.IsZero() {
+			metrics.DownstreamWait(name, start.Sub(delivered))
+		}
+		return io.Fold(
+			io.IO[StepResult[A]](stm),
+			func(sra StepResult[A]) io.IO[StepResult[A]] {
+				return io.Pure(func() StepResult[A] {
+					end := time.Now()
+					metrics.UpstreamWait(name, end.Sub(start))
+					if !sra.IsFinished {
+						n := len(sra.Chunk)
+						if sra.HasValue && n == 0 {
+							n = 1
+						}
+						if n > 0 {
+							metrics.Elements(name, n)
+						}
+						sra.Continuation = instrumentStep(metrics, name, sra.Continuation, end)
+					}
+					return sra
+				})
+			},
+			func(err error) io.IO[StepResult[A]] {
+				metrics.UpstreamWait(name, time.Since(start))
+				metrics.Error(name)
+				return io.Fail[StepResult[A]](err)
+			},
+		)
+	}))
+}
@@ -0,0 +1,54 @@
+package stream_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrument(t *testing.T) {
+	registry := stream.NewMetricsRegistry()
+	slow := stream.MapEval(stream.LiftMany(1, 2, 3), func(i int) io.IO[int] {
+		return io.SleepA(10*time.Millisecond, i)
+	})
+	instrumented := stream.InstrumentWith(registry, "slow", slow)
+	chunked := stream.InstrumentWith(registry, "chunked", stream.FromSlice([]int{1, 2, 3, 4}))
+	assert.Equal(t, []int{1, 2, 3}, UnsafeStreamToSlice(t, instrumented))
+	assert.Equal(t, []int{1, 2, 3, 4}, UnsafeStreamToSlice(t, chunked))
+	UnsafeIOExpectError(t, errExpected, stream.ToSlice(stream.InstrumentWith(registry, "failing", natsAndThenFail)))
+
+	snapshot := UnsafeIO(t, registry.Snapshot())
+	assert.Equal(t, int64(3), snapshot["slow"].Elements)
+	assert.GreaterOrEqual(t, snapshot["slow"].UpstreamWait, 30*time.Millisecond)
+	assert.Equal(t, int64(4), snapshot["chunked"].Elements)
+	assert.Equal(t, int64(10), snapshot["failing"].Elements)
+	assert.Equal(t, int64(1), snapshot["failing"].Errors)
+}
+
+func TestInstrumentDownstreamWait(t *testing.T) {
+	registry := stream.NewMetricsRegistry()
+	instrumented := stream.InstrumentWith(registry, "fast", stream.LiftMany(1, 2, 3))
+	slowConsumer := stream.MapEval(instrumented, func(i int) io.IO[int] {
+		return io.SleepA(10*time.Millisecond, i)
+	})
+	UnsafeStreamToSlice(t, slowConsumer)
+	snapshot := UnsafeIO(t, registry.Snapshot())
+	assert.GreaterOrEqual(t, snapshot["fast"].DownstreamWait, 30*time.Millisecond)
+}
+
+func TestWritePrometheus(t *testing.T) {
+	registry := stream.NewMetricsRegistry()
+	registry.Elements("parse", 10)
+	registry.Error(`a"b`)
+	var sb strings.Builder
+	UnsafeIO(t, registry.WritePrometheus(&sb))
+	text := sb.String()
+	assert.Contains(t, text, "# TYPE goio_stream_elements_total counter\n")
+	assert.Contains(t, text, "goio_stream_elements_total{stage=\"parse\"} 10\n")
+	assert.Contains(t, text, "goio_stream_errors_total{stage=\"a\\\"b\"} 1\n")
+	assert.Contains(t, text, "goio_stream_upstream_wait_seconds_total{stage=\"parse\"} 0\n")
+}
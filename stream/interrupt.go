@@ -0,0 +1,117 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+)
+
+// InterruptWhen stops the stream as soon as the signal completes.
+// The signal is started in a separate go routine when the stream is evaluated.
+// If the signal fails, the stream fails with the same error.
+// On interruption the rest of the stream is finalized.
+// When the stream completes, fails or is abandoned, the signal is stopped before it's next step.
+// NB! A step that is already running (e.g. blocked on reading) is not interrupted.
+// The stream stops before the next step. The same applies to the signal -
+// a single blocking step (like io.Never) keeps it's go routine until it returns.
+func InterruptWhen[A any](stm Stream[A], signal io.IO[fun.Unit]) Stream[A] {
+	return Stream[A](io.Delay(func() io.IO[StepResult[A]] {
+		var mu sync.Mutex
+		var result *io.GoResult[fun.Unit]
+		done := make(chan struct{})
+		check := io.Eval(func() (bool, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if result == nil {
+				return false, nil
+			} else {
+				return true, result.Error
+			}
+		})
+		notify := io.FromPureEffect(func() {
+			res, completed := runUntilDone(signal, done)
+			if completed {
+				mu.Lock()
+				defer mu.Unlock()
+				result = &res
+			}
+		})
+		stop := io.FromPureEffect(func() { close(done) })
+		return io.AndThen(
+			io.FireAndForget(notify),
+			io.IO[StepResult[A]](OnFinalize(interruptible(stm, nil, check), stop)),
+		)
+	}))
+}
+
+// runUntilDone evaluates the io step-by-step until it completes or done is closed.
+// completed is false when the evaluation has been stopped.
+func runUntilDone[A any](ioa io.IO[A], done <-chan struct{}) (res io.GoResult[A], completed bool) {
+	defer fun.RecoverToErrorVar("runUntilDone", &res.Error)
+	cont := io.Continuation[A](ioa)
+	for {
+		select {
+		case <-done:
+			return res, false
+		default:
+		}
+		completed = true // a panic during the step completes the evaluation with an error
+		step := cont()
+		if step.Continuation == nil {
+			return io.GoResult[A]{Value: step.Value, Error: step.Error}, true
+		}
+		cont = *step.Continuation
+	}
+}
+
+// InterruptAfter stops the stream when the given duration has elapsed
+// since the start of the stream evaluation.
+// On interruption the rest of the stream is finalized.
+// NB! A step that is already running is not interrupted.
+func InterruptAfter[A any](stm Stream[A], d time.Duration) Stream[A] {
+	return Stream[A](io.Delay(func() io.IO[StepResult[A]] {
+		deadline := time.Now().Add(d)
+		check := io.Pure(func() bool {
+			return !time.Now().Before(deadline)
+		})
+		return io.IO[StepResult[A]](interruptible(stm, nil, check))
+	}))
+}
+
+// HaltWhenTrue stops the stream as soon as the reference becomes true.
+// It could be used to gracefully shutdown a long-running stream from another go routine,
+// for instance, on SIGTERM.
+// On interruption the rest of the stream is finalized.
+// NB! A step that is already running is not interrupted.
+func HaltWhenTrue[A any](stm Stream[A], ref SignallingRef[bool]) Stream[A] {
+	return interruptible(stm, nil, ref.Get())
+}
+
+// interruptible evaluates check before each step of the stream.
+// When check returns true, the stream finishes and
+// the finalizer of the previous step is executed.
+func interruptible[A any](stm Stream[A], fin io.IOUnit, check io.IO[bool]) Stream[A] {
+	if fin == nil {
+		fin = io.IOUnit1
+	}
+	return Stream[A](io.Fold(
+		check,
+		func(interrupted bool) io.IO[StepResult[A]] {
+			if interrupted {
+				return io.AndThen(fin, LazyFinishedStepResult[A]())
+			} else {
+				return io.Map(io.IO[StepResult[A]](stm), func(sra StepResult[A]) StepResult[A] {
+					if !sra.IsFinished {
+						sra.Continuation = interruptible(sra.Continuation, sra.Finalizer, check)
+					}
+					return sra
+				})
+			}
+		},
+		func(err error) io.IO[StepResult[A]] {
+			return io.Finally(io.Fail[StepResult[A]](err), fin)
+		},
+	))
+}
@@ -0,0 +1,84 @@
+package stream_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterruptWhen(t *testing.T) {
+	count := 0
+	finalizer := io.FromPureEffect(func() { count += 1 })
+	slowNats := stream.MapEval(stream.OnFinalize(nats, finalizer), func(i int) io.IO[int] {
+		return io.SleepA(time.Millisecond, i)
+	})
+	interrupted := stream.InterruptWhen(slowNats, io.Sleep(20*time.Millisecond))
+	res := UnsafeStreamToSlice(t, interrupted)
+	assert.NotEmpty(t, res)
+	assert.Equal(t, 1, count)
+
+	failed := stream.InterruptWhen(slowNats, io.AndThen(io.Sleep(20*time.Millisecond), io.Fail[fun.Unit](errExpected)))
+	UnsafeIOExpectError(t, errExpected, stream.DrainAll(failed))
+	assert.Equal(t, 2, count)
+
+	assert.Equal(t, nats10Values, UnsafeStreamToSlice(t, stream.InterruptWhen(nats10, pollForever(new(int32)))))
+}
+
+// pollForever is a signal that never completes. It counts it's steps.
+func pollForever(steps *int32) io.IO[fun.Unit] {
+	return io.FlatMap(io.Sleep(time.Millisecond), func(fun.Unit) io.IO[fun.Unit] {
+		atomic.AddInt32(steps, 1)
+		return pollForever(steps)
+	})
+}
+
+func TestInterruptWhenStopsSignal(t *testing.T) {
+	var steps int32
+	slowNats10 := stream.MapEval(nats10, func(i int) io.IO[int] {
+		return io.SleepA(time.Millisecond, i)
+	})
+	assert.Equal(t, nats10Values, UnsafeStreamToSlice(t, stream.InterruptWhen(slowNats10, pollForever(&steps))))
+	time.Sleep(50 * time.Millisecond)
+	stepsAfterCompletion := atomic.LoadInt32(&steps)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, stepsAfterCompletion, atomic.LoadInt32(&steps))
+}
+
+func TestInterruptAfter(t *testing.T) {
+	count := 0
+	finalizer := io.FromPureEffect(func() { count += 1 })
+	interrupted := stream.InterruptAfter(stream.OnFinalize(stream.Repeat(stream.Lift(1)), finalizer), 10*time.Millisecond)
+	assert.NotEmpty(t, UnsafeStreamToSlice(t, interrupted))
+	assert.Equal(t, 1, count)
+}
+
+func TestHaltWhenTrue(t *testing.T) {
+	ref := UnsafeIO(t, stream.NewSignallingRef(false))
+	halting := stream.HaltWhenTrue(stream.SideEval(nats, func(i int) io.IOUnit {
+		if i == 5 {
+			return ref.Set(true)
+		} else {
+			return io.IOUnit1
+		}
+	}), ref)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, UnsafeStreamToSlice(t, halting))
+}
+
+func TestSignallingRef(t *testing.T) {
+	ref := UnsafeIO(t, stream.NewSignallingRef(0))
+	waiting := UnsafeIO(t, io.Start(ref.WaitUntil(func(i int) bool { return i >= 3 })))
+	changes := UnsafeIO(t, io.Start(stream.ToSlice(stream.Take(ref.Discrete(), 2))))
+	for i := 1; i <= 3; i++ {
+		UnsafeIO(t, io.AndThen(io.Sleep(time.Millisecond), ref.Update(func(i int) int { return i + 1 })))
+	}
+	assert.Equal(t, 3, UnsafeIO(t, waiting.Join()))
+	values := UnsafeIO(t, changes.Join())
+	assert.Equal(t, 0, values[0])
+	assert.Greater(t, values[1], 0)
+	assert.Equal(t, 3, UnsafeIO(t, ref.Get()))
+}
@@ -0,0 +1,111 @@
+//go:build go1.23
+
+package stream
+
+import (
+	"iter"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/option"
+)
+
+// ToIterator converts the stream to an iterator that could be used in `for range`.
+// Each element is yielded with a nil error.
+// If the stream fails, the error is yielded once with a zero value and the iteration stops.
+// When the loop is stopped early, the rest of the stream is finalized.
+func ToIterator[A any](stm Stream[A]) iter.Seq2[A, error] {
+	return func(yield func(A, error) bool) {
+		cursor, _ := io.UnsafeRunSync(NewCursor(stm))
+		defer io.UnsafeRunSync(cursor.Close())
+		for {
+			oa, err := io.UnsafeRunSync(cursor.Next())
+			if err != nil {
+				var zero A
+				yield(zero, err)
+				return
+			} else if option.IsEmpty(oa) {
+				return
+			} else if !yield(option.Get(oa), nil) {
+				return
+			}
+		}
+	}
+}
+
+// ToSeq converts the stream to a sequence that could be used in `for range`.
+// If the stream fails, the iteration stops. The error is returned
+// by the second function which should be checked after the loop (like bufio.Scanner.Err).
+// When the loop is stopped early, the rest of the stream is finalized.
+func ToSeq[A any](stm Stream[A]) (iter.Seq[A], func() error) {
+	var failure error
+	seq := func(yield func(A) bool) {
+		failure = nil
+		for a, err := range ToIterator(stm) {
+			if err != nil {
+				failure = err
+				return
+			} else if !yield(a) {
+				return
+			}
+		}
+	}
+	return seq, func() error { return failure }
+}
+
+// FromSeq constructs a stream from the sequence.
+// The sequence is started on each evaluation of the stream.
+// When the stream is abandoned, the sequence is stopped.
+func FromSeq[A any](seq iter.Seq[A]) Stream[A] {
+	return Stream[A](io.Delay(func() io.IO[StepResult[A]] {
+		next, stop := iter.Pull(seq)
+		return io.IO[StepResult[A]](OnFinalize(fromPull(next), io.FromPureEffect(stop)))
+	}))
+}
+
+func fromPull[A any](next func() (A, bool)) Stream[A] {
+	return FromStepResult(io.Pure(func() StepResult[A] {
+		a, ok := next()
+		if ok {
+			return NewStepResult(a, fromPull(next))
+		} else {
+			return NewStepResultFinished[A]()
+		}
+	}))
+}
+
+// FromSeq2 constructs a stream from the sequence of elements and errors.
+// The stream fails on the first non-nil error.
+// When the stream is abandoned, the sequence is stopped.
+func FromSeq2[A any](seq iter.Seq2[A, error]) Stream[A] {
+	return Stream[A](io.Delay(func() io.IO[StepResult[A]] {
+		next, stop := iter.Pull2(seq)
+		return io.IO[StepResult[A]](OnFinalize(fromPull2(next), io.FromPureEffect(stop)))
+	}))
+}
+
+func fromPull2[A any](next func() (A, error, bool)) Stream[A] {
+	return FromStepResult(io.Eval(func() (sra StepResult[A], err error) {
+		a, err1, ok := next()
+		if !ok {
+			sra = NewStepResultFinished[A]()
+		} else if err1 != nil {
+			err = err1
+		} else {
+			sra = NewStepResult(a, fromPull2(next))
+		}
+		return
+	}))
+}
+
+// FromSeqPairs constructs a stream of pairs from the sequence of pairs.
+// It could be used with `maps.All`, `slices.All`, etc.
+func FromSeqPairs[K any, V any](seq iter.Seq2[K, V]) Stream[fun.Pair[K, V]] {
+	return FromSeq(func(yield func(fun.Pair[K, V]) bool) {
+		for k, v := range seq {
+			if !yield(fun.NewPair(k, v)) {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,91 @@
+//go:build go1.23
+
+package stream_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToIterator(t *testing.T) {
+	results := []int{}
+	var failure error
+	for i, err := range stream.ToIterator(natsAndThenFail) {
+		if err != nil {
+			failure = err
+		} else {
+			results = append(results, i)
+		}
+	}
+	assert.Equal(t, nats10Values, results)
+	assert.Equal(t, errExpected, failure)
+}
+
+func TestToIteratorBreak(t *testing.T) {
+	count := 0
+	finalizer := io.FromPureEffect(func() { count += 1 })
+	sum := 0
+	for i, err := range stream.ToIterator(stream.OnFinalize(nats, finalizer)) {
+		assert.NoError(t, err)
+		if i > 4 {
+			break
+		}
+		sum += i
+	}
+	assert.Equal(t, 10, sum)
+	assert.Equal(t, 1, count)
+}
+
+func TestToSeq(t *testing.T) {
+	seq, errf := stream.ToSeq(natsAndThenFail)
+	assert.Equal(t, nats10Values, slices.Collect(seq))
+	assert.Equal(t, errExpected, errf())
+
+	seq, errf = stream.ToSeq(nats)
+	sum := 0
+	for i := range seq {
+		if i > 4 {
+			break
+		}
+		sum += i
+	}
+	assert.Equal(t, 10, sum)
+	assert.NoError(t, errf())
+}
+
+func TestFromSeq(t *testing.T) {
+	fromSeq := stream.FromSeq(slices.Values(nats10Values))
+	assert.Equal(t, nats10Values, UnsafeStreamToSlice(t, fromSeq))
+	// the stream could be evaluated again
+	assert.Equal(t, []int{1, 2}, UnsafeStreamToSlice(t, stream.Take(fromSeq, 2)))
+
+	stopped := false
+	infinite := func(yield func(int) bool) {
+		defer func() { stopped = true }()
+		for i := 1; yield(i); i++ {
+		}
+	}
+	assert.Equal(t, []int{1, 2, 3}, UnsafeStreamToSlice(t, stream.Take(stream.FromSeq(infinite), 3)))
+	assert.True(t, stopped)
+}
+
+func TestFromSeq2(t *testing.T) {
+	seq2 := func(yield func(int, error) bool) {
+		_ = yield(1, nil) && yield(2, nil) && yield(0, errExpected) && yield(3, nil)
+	}
+	results := []int{}
+	UnsafeIOExpectError(t, errExpected, stream.ForEach(stream.FromSeq2(seq2), func(i int) {
+		results = append(results, i)
+	}))
+	assert.Equal(t, []int{1, 2}, results)
+}
+
+func TestFromSeqPairs(t *testing.T) {
+	pairs := UnsafeStreamToSlice(t, stream.FromSeqPairs(slices.All([]string{"a", "b"})))
+	assert.Equal(t, []fun.Pair[int, string]{fun.NewPair(0, "a"), fun.NewPair(1, "b")}, pairs)
+}
@@ -0,0 +1,122 @@
+package stream
+
+import (
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/option"
+	"github.com/primetalk/goio/slice"
+)
+
+// JoinSorted performs full outer join of two streams that are sorted by key according to cmp.
+// cmp returns a negative number when the first key is less than the second one, zero when they are equal
+// and a positive number otherwise.
+// Elements with equal keys are joined pairwise (cartesian product).
+// Elements without a match are paired with None.
+// Only elements with the same key are kept in memory.
+func JoinSorted[A any, B any, K any](left Stream[A], right Stream[B],
+	keyL func(A) K, keyR func(B) K, cmp func(K, K) int,
+) Stream[fun.Pair[option.Option[A], option.Option[B]]] {
+	return joinSorted(left, right, keyL, keyR, cmp, true, true)
+}
+
+// LeftJoinSorted performs left outer join of two streams that are sorted by key.
+// See JoinSorted.
+func LeftJoinSorted[A any, B any, K any](left Stream[A], right Stream[B],
+	keyL func(A) K, keyR func(B) K, cmp func(K, K) int,
+) Stream[fun.Pair[option.Option[A], option.Option[B]]] {
+	return joinSorted(left, right, keyL, keyR, cmp, true, false)
+}
+
+// InnerJoinSorted performs inner join of two streams that are sorted by key.
+// Both options of the result are always defined. See JoinSorted.
+func InnerJoinSorted[A any, B any, K any](left Stream[A], right Stream[B],
+	keyL func(A) K, keyR func(B) K, cmp func(K, K) int,
+) Stream[fun.Pair[option.Option[A], option.Option[B]]] {
+	return joinSorted(left, right, keyL, keyR, cmp, false, false)
+}
+
+// joinGroup contains elements of one of the streams with the same key.
+type joinGroup[A any, B any, K any] struct {
+	key    K
+	isLeft bool
+	left   []A
+	right  []B
+}
+
+func joinSorted[A any, B any, K any](left Stream[A], right Stream[B],
+	keyL func(A) K, keyR func(B) K, cmp func(K, K) int,
+	unmatchedLeft bool, unmatchedRight bool,
+) Stream[fun.Pair[option.Option[A], option.Option[B]]] {
+	leftGroups := Map(groupSortedBy(left, keyL, cmp), func(p fun.Pair[K, []A]) joinGroup[A, B, K] {
+		return joinGroup[A, B, K]{key: p.V1, isLeft: true, left: p.V2}
+	})
+	rightGroups := Map(groupSortedBy(right, keyR, cmp), func(p fun.Pair[K, []B]) joinGroup[A, B, K] {
+		return joinGroup[A, B, K]{key: p.V1, right: p.V2}
+	})
+	// left groups go first for equal keys.
+	groups := MergeSorted(func(g1, g2 joinGroup[A, B, K]) bool { return cmp(g1.key, g2.key) < 0 }, leftGroups, rightGroups)
+	flush := func(pending option.Option[joinGroup[A, B, K]]) Stream[fun.Pair[option.Option[A], option.Option[B]]] {
+		if option.IsDefined(pending) && unmatchedLeft {
+			return FromSlice(slice.Map(option.Get(pending).left, func(a A) fun.Pair[option.Option[A], option.Option[B]] {
+				return fun.NewPair(option.Some(a), option.None[B]())
+			}))
+		} else {
+			return Empty[fun.Pair[option.Option[A], option.Option[B]]]()
+		}
+	}
+	return StateFlatMapWithFinish(groups, option.None[joinGroup[A, B, K]](),
+		func(g joinGroup[A, B, K], pending option.Option[joinGroup[A, B, K]]) io.IO[fun.Pair[option.Option[joinGroup[A, B, K]], Stream[fun.Pair[option.Option[A], option.Option[B]]]]] {
+			return io.Pure(func() fun.Pair[option.Option[joinGroup[A, B, K]], Stream[fun.Pair[option.Option[A], option.Option[B]]]] {
+				if g.isLeft {
+					return fun.NewPair(option.Some(g), flush(pending))
+				} else if option.IsDefined(pending) && cmp(option.Get(pending).key, g.key) == 0 {
+					matched := slice.FlatMap(option.Get(pending).left, func(a A) []fun.Pair[option.Option[A], option.Option[B]] {
+						return slice.Map(g.right, func(b B) fun.Pair[option.Option[A], option.Option[B]] {
+							return fun.NewPair(option.Some(a), option.Some(b))
+						})
+					})
+					return fun.NewPair(option.None[joinGroup[A, B, K]](), FromSlice(matched))
+				} else {
+					unmatched := Empty[fun.Pair[option.Option[A], option.Option[B]]]()
+					if unmatchedRight {
+						unmatched = FromSlice(slice.Map(g.right, func(b B) fun.Pair[option.Option[A], option.Option[B]] {
+							return fun.NewPair(option.None[A](), option.Some(b))
+						}))
+					}
+					return fun.NewPair(option.None[joinGroup[A, B, K]](), AndThen(flush(pending), unmatched))
+				}
+			})
+		},
+		flush,
+	)
+}
+
+// groupSortedBy collects consecutive elements with equal keys.
+func groupSortedBy[A any, K any](stm Stream[A], key func(A) K, cmp func(K, K) int) Stream[fun.Pair[K, []A]] {
+	return StateFlatMapWithFinish(stm, option.None[fun.Pair[K, []A]](),
+		func(a A, s option.Option[fun.Pair[K, []A]]) io.IO[fun.Pair[option.Option[fun.Pair[K, []A]], Stream[fun.Pair[K, []A]]]] {
+			return io.Pure(func() fun.Pair[option.Option[fun.Pair[K, []A]], Stream[fun.Pair[K, []A]]] {
+				k := key(a)
+				if option.IsDefined(s) && cmp(option.Get(s).V1, k) == 0 {
+					g := option.Get(s)
+					return fun.NewPair(option.Some(fun.NewPair(k, append(g.V2, a))), Empty[fun.Pair[K, []A]]())
+				} else {
+					return fun.NewPair(option.Some(fun.NewPair(k, []A{a})), FromOption(s))
+				}
+			})
+		},
+		FromOption[fun.Pair[K, []A]],
+	)
+}
+
+// JoinWithIndex performs hash join of the stream with an index.
+// Each element is paired with all values in the index that have the same key.
+// Elements without a match are dropped.
+// The index could be built with slice.BuildIndex.
+func JoinWithIndex[A any, B any, K comparable](stm Stream[A], key func(A) K, index map[K][]B) Stream[fun.Pair[A, B]] {
+	return FlatMap(stm, func(a A) Stream[fun.Pair[A, B]] {
+		return FromSlice(slice.Map(index[key(a)], func(b B) fun.Pair[A, B] {
+			return fun.NewPair(a, b)
+		}))
+	})
+}
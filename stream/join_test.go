@@ -0,0 +1,50 @@
+package stream_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/option"
+	"github.com/primetalk/goio/slice"
+	"github.com/primetalk/goio/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+type joinRow = fun.Pair[option.Option[int], option.Option[string]]
+
+func cmpInt(a, b int) int { return a - b }
+
+func showJoin(rows []joinRow) string {
+	return strings.Join(slice.Map(rows, func(r joinRow) string {
+		return option.Match(option.Map(r.V1, fun.ToString[int]), fun.Identity[string], fun.ConstNoArg("_")) +
+			":" + option.Match(r.V2, fun.Identity[string], fun.ConstNoArg("_"))
+	}), " ")
+}
+
+var joinLeft = stream.LiftMany(1, 2, 2, 4)
+var joinRight = stream.LiftMany("2a", "2b", "3", "4")
+
+func keyOfString(s string) int { return int(s[0] - '0') }
+
+func TestJoinSorted(t *testing.T) {
+	full := stream.JoinSorted(joinLeft, joinRight, fun.Identity[int], keyOfString, cmpInt)
+	assert.Equal(t, "1:_ 2:2a 2:2b 2:2a 2:2b _:3 4:4", showJoin(UnsafeStreamToSlice(t, full)))
+
+	left := stream.LeftJoinSorted(joinLeft, joinRight, fun.Identity[int], keyOfString, cmpInt)
+	assert.Equal(t, "1:_ 2:2a 2:2b 2:2a 2:2b 4:4", showJoin(UnsafeStreamToSlice(t, left)))
+
+	inner := stream.InnerJoinSorted(joinLeft, joinRight, fun.Identity[int], keyOfString, cmpInt)
+	assert.Equal(t, "2:2a 2:2b 2:2a 2:2b 4:4", showJoin(UnsafeStreamToSlice(t, inner)))
+
+	onlyRight := stream.JoinSorted(stream.Empty[int](), joinRight, fun.Identity[int], keyOfString, cmpInt)
+	assert.Equal(t, "_:2a _:2b _:3 _:4", showJoin(UnsafeStreamToSlice(t, onlyRight)))
+}
+
+func TestJoinWithIndex(t *testing.T) {
+	index := slice.BuildIndex([]string{"2a", "2b", "3"}, keyOfString)
+	joined := stream.JoinWithIndex(stream.LiftMany(1, 2, 3), fun.Identity[int], index)
+	assert.Equal(t, []fun.Pair[int, string]{
+		fun.NewPair(2, "2a"), fun.NewPair(2, "2b"), fun.NewPair(3, "3"),
+	}, UnsafeStreamToSlice(t, joined))
+}
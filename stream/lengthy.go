@@ -8,6 +8,7 @@ func Repeat[A any](stm Stream[A]) Stream[A] {
 }
 
 // Take cuts the stream after n elements.
+// The rest of the original stream is finalized.
 func Take[A any](stm Stream[A], n int) Stream[A] {
 	if n <= 0 {
 		return Empty[A]()
@@ -16,10 +17,19 @@ func Take[A any](stm Stream[A], n int) Stream[A] {
 			io.IO[StepResult[A]](stm),
 			func(sra StepResult[A]) StepResult[A] {
 				nextCount := n
-				if sra.HasValue {
+				if len(sra.Chunk) > 0 {
+					if len(sra.Chunk) > n {
+						sra.Chunk = sra.Chunk[:n]
+					}
+					nextCount = n - len(sra.Chunk)
+				} else if sra.HasValue {
 					nextCount = n - 1
 				}
-				sra.Continuation = Take(sra.Continuation, nextCount)
+				if nextCount <= 0 {
+					sra.Continuation = abandon(sra)
+				} else {
+					sra.Continuation = Take(sra.Continuation, nextCount)
+				}
 				return sra
 			}))
 	}
@@ -33,24 +43,37 @@ func Drop[A any](stm Stream[A], n int) Stream[A] {
 		return Stream[A](io.Map(
 			io.IO[StepResult[A]](stm),
 			func(sra StepResult[A]) StepResult[A] {
-				sra.Continuation = Drop(sra.Continuation, n-1)
-				sra.HasValue = false
+				if len(sra.Chunk) > n {
+					sra.Chunk = sra.Chunk[n:]
+				} else if len(sra.Chunk) > 0 {
+					sra.Continuation = Drop(sra.Continuation, n-len(sra.Chunk))
+					sra.Chunk = nil
+				} else if sra.HasValue {
+					sra.Continuation = Drop(sra.Continuation, n-1)
+					sra.HasValue = false
+				} else {
+					sra.Continuation = Drop(sra.Continuation, n)
+				}
 				return sra
 			}))
 	}
 }
 
 // TakeWhile returns the beginning of the stream such that all elements satisfy the predicate.
+// The rest of the original stream is finalized.
 func TakeWhile[A any](stm Stream[A], predicate func(A) bool) Stream[A] {
-	return Stream[A](io.Map(
+	return Stream[A](io.FlatMap(
 		io.IO[StepResult[A]](stm),
-		func(sra StepResult[A]) StepResult[A] {
-			if sra.IsFinished || (sra.HasValue && !predicate(sra.Value)) {
-				sra.IsFinished = true
+		func(sra StepResult[A]) io.IO[StepResult[A]] {
+			sra = uncons(sra)
+			if sra.IsFinished {
+				return io.Lift(sra)
+			} else if sra.HasValue && !predicate(sra.Value) {
+				return io.AndThen(finalizer(sra), LazyFinishedStepResult[A]())
 			} else {
 				sra.Continuation = TakeWhile(sra.Continuation, predicate)
+				return io.Lift(sra)
 			}
-			return sra
 		}))
 }
 
@@ -60,6 +83,7 @@ func DropWhile[A any](stm Stream[A], predicate func(A) bool) Stream[A] {
 	return Stream[A](io.Map(
 		io.IO[StepResult[A]](stm),
 		func(sra StepResult[A]) StepResult[A] {
+			sra = uncons(sra)
 			if !sra.IsFinished && (sra.HasValue && predicate(sra.Value)) {
 				sra.HasValue = false
 				sra.Continuation = DropWhile(sra.Continuation, predicate)
@@ -0,0 +1,116 @@
+package stream
+
+import (
+	"container/heap"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/slice"
+)
+
+// MergeSorted merges streams that are sorted according to less into a single sorted stream.
+// Elements that are equal are taken from the streams in the order of arguments.
+// Streams are evaluated lazily - only one element of each stream is kept in memory.
+// When the result is abandoned or fails, the rest of all streams is finalized.
+func MergeSorted[A any](less func(A, A) bool, streams ...Stream[A]) Stream[A] {
+	return Stream[A](io.Delay(func() io.IO[StepResult[A]] {
+		h := &mergeHeap[A]{less: less}
+		pulls := slice.Map(slice.Range(0, len(streams)), func(i int) io.IOUnit {
+			return h.pull(i, streams[i])
+		})
+		return io.AndThen(
+			io.SequenceUnit(pulls),
+			io.IO[StepResult[A]](h.merge()),
+		)
+	}))
+}
+
+// mergeHeadItem is the current head of one of the merged streams.
+type mergeHeadItem[A any] struct {
+	value A
+	index int
+	rest  Stream[A]
+	fin   io.IOUnit
+}
+
+// mergeHeap keeps the heads of the streams that haven't finished yet.
+type mergeHeap[A any] struct {
+	less  func(A, A) bool
+	items []mergeHeadItem[A]
+}
+
+func (h *mergeHeap[A]) Len() int { return len(h.items) }
+
+func (h *mergeHeap[A]) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	return h.less(a.value, b.value) || (!h.less(b.value, a.value) && a.index < b.index)
+}
+
+func (h *mergeHeap[A]) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *mergeHeap[A]) Push(x any) { h.items = append(h.items, x.(mergeHeadItem[A])) }
+
+func (h *mergeHeap[A]) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// finalizer releases all streams that are in the heap.
+func (h *mergeHeap[A]) finalizer() io.IOUnit {
+	return io.Delay(func() io.IOUnit {
+		var fin io.IOUnit
+		for _, item := range h.items {
+			fin = combineFinalizers(fin, item.fin)
+		}
+		if fin == nil {
+			return io.IOUnit1
+		} else {
+			return fin
+		}
+	})
+}
+
+// pull evaluates the stream until the first element and puts it into the heap.
+// On failure all other streams are finalized.
+func (h *mergeHeap[A]) pull(index int, stm Stream[A]) io.IOUnit {
+	return io.Fold(
+		io.IO[StepResult[A]](stm),
+		func(sra StepResult[A]) io.IOUnit {
+			sra = uncons(sra)
+			if sra.IsFinished {
+				return io.IOUnit1
+			} else if sra.HasValue {
+				return io.FromPureEffect(func() {
+					heap.Push(h, mergeHeadItem[A]{
+						value: sra.Value,
+						index: index,
+						rest:  sra.Continuation,
+						fin:   sra.Finalizer,
+					})
+				})
+			} else {
+				return h.pull(index, sra.Continuation)
+			}
+		},
+		func(err error) io.IOUnit {
+			return io.Finally(io.Fail[fun.Unit](err), h.finalizer())
+		},
+	)
+}
+
+// merge emits the smallest head and pulls the next element from the same stream.
+func (h *mergeHeap[A]) merge() Stream[A] {
+	return FromStepResult(io.Pure(func() StepResult[A] {
+		if h.Len() == 0 {
+			return NewStepResultFinished[A]()
+		}
+		item := heap.Pop(h).(mergeHeadItem[A])
+		sra := NewStepResult(item.value, Stream[A](io.AndThen(
+			h.pull(item.index, item.rest),
+			io.IO[StepResult[A]](h.merge()),
+		)))
+		sra.Finalizer = combineFinalizers(item.fin, h.finalizer())
+		return sra
+	}))
+}
@@ -0,0 +1,44 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func lessInt(a, b int) bool { return a < b }
+
+func TestMergeSorted(t *testing.T) {
+	merged := stream.MergeSorted(lessInt,
+		stream.LiftMany(1, 4, 7, 10),
+		stream.Empty[int](),
+		stream.LiftMany(2, 5, 8),
+		stream.Filter(stream.LiftMany(3, 6, 9), fun.Const[int](true)),
+	)
+	assert.Equal(t, nats10Values, UnsafeStreamToSlice(t, merged))
+	assert.Empty(t, UnsafeStreamToSlice(t, stream.MergeSorted[int](lessInt)))
+}
+
+func TestMergeSortedIsStable(t *testing.T) {
+	lessV1 := func(a, b fun.Pair[int, string]) bool { return a.V1 < b.V1 }
+	merged := stream.MergeSorted(lessV1,
+		stream.LiftMany(fun.NewPair(1, "a"), fun.NewPair(2, "a")),
+		stream.LiftMany(fun.NewPair(1, "b"), fun.NewPair(2, "b")),
+	)
+	assert.Equal(t, []string{"a", "b", "a", "b"}, UnsafeStreamToSlice(t, stream.Map(merged, fun.PairV2[int, string])))
+}
+
+func TestMergeSortedFinalization(t *testing.T) {
+	count := 0
+	finalizer := io.FromPureEffect(func() { count += 1 })
+	evens := stream.Map(stream.OnFinalize(nats, finalizer), func(i int) int { return i * 2 })
+	odds := stream.Map(stream.OnFinalize(nats, finalizer), func(i int) int { return i*2 - 1 })
+	assert.Equal(t, []int{1, 2, 3, 4}, UnsafeStreamToSlice(t, stream.Take(stream.MergeSorted(lessInt, evens, odds), 4)))
+	assert.Equal(t, 2, count)
+
+	UnsafeIOExpectError(t, errExpected, stream.DrainAll(stream.MergeSorted(lessInt, evens, natsAndThenFail)))
+	assert.Equal(t, 3, count)
+}
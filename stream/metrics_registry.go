@@ -0,0 +1,151 @@
+package stream
+
+import (
+	"fmt"
+	fio "io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/primetalk/goio/io"
+)
+
+// StageMetrics contains accumulated measurements of a single stage.
+type StageMetrics struct {
+	Elements       int64
+	Errors         int64
+	UpstreamWait   time.Duration
+	DownstreamWait time.Duration
+}
+
+// MetricsRegistry is an in-memory implementation of Metrics.
+type MetricsRegistry interface {
+	Metrics
+	// Snapshot returns a copy of the current metrics by stage name.
+	Snapshot() io.IO[map[string]StageMetrics]
+	// WritePrometheus writes the current metrics in Prometheus text exposition format.
+	WritePrometheus(w fio.Writer) io.IOUnit
+}
+
+type metricsRegistryImpl struct {
+	mu     sync.Mutex
+	stages map[string]*StageMetrics
+}
+
+// NewMetricsRegistry creates an empty registry.
+func NewMetricsRegistry() MetricsRegistry {
+	return &metricsRegistryImpl{
+		stages: map[string]*StageMetrics{},
+	}
+}
+
+// update modifies metrics of the stage under lock.
+func (r *metricsRegistryImpl) update(stage string, f func(m *StageMetrics)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.stages[stage]
+	if !ok {
+		m = &StageMetrics{}
+		r.stages[stage] = m
+	}
+	f(m)
+}
+
+func (r *metricsRegistryImpl) Elements(stage string, n int) {
+	r.update(stage, func(m *StageMetrics) { m.Elements += int64(n) })
+}
+
+func (r *metricsRegistryImpl) Error(stage string) {
+	r.update(stage, func(m *StageMetrics) { m.Errors += 1 })
+}
+
+func (r *metricsRegistryImpl) UpstreamWait(stage string, d time.Duration) {
+	r.update(stage, func(m *StageMetrics) { m.UpstreamWait += d })
+}
+
+func (r *metricsRegistryImpl) DownstreamWait(stage string, d time.Duration) {
+	r.update(stage, func(m *StageMetrics) { m.DownstreamWait += d })
+}
+
+func (r *metricsRegistryImpl) Snapshot() io.IO[map[string]StageMetrics] {
+	return io.Pure(func() map[string]StageMetrics {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		res := make(map[string]StageMetrics, len(r.stages))
+		for stage, m := range r.stages {
+			res[stage] = *m
+		}
+		return res
+	})
+}
+
+func (r *metricsRegistryImpl) WritePrometheus(w fio.Writer) io.IOUnit {
+	return io.FlatMap(r.Snapshot(), func(snapshot map[string]StageMetrics) io.IOUnit {
+		return io.FromUnit(func() error {
+			_, err := fio.WriteString(w, formatPrometheus(snapshot))
+			return err
+		})
+	})
+}
+
+// prometheusMetric describes one of the exported metrics.
+type prometheusMetric struct {
+	name  string
+	help  string
+	value func(m StageMetrics) string
+}
+
+var prometheusMetrics = []prometheusMetric{
+	{
+		name:  "goio_stream_elements_total",
+		help:  "Number of elements emitted by the stage.",
+		value: func(m StageMetrics) string { return strconv.FormatInt(m.Elements, 10) },
+	},
+	{
+		name:  "goio_stream_errors_total",
+		help:  "Number of failures of the stage.",
+		value: func(m StageMetrics) string { return strconv.FormatInt(m.Errors, 10) },
+	},
+	{
+		name:  "goio_stream_upstream_wait_seconds_total",
+		help:  "Time spent waiting for the upstream to produce elements.",
+		value: func(m StageMetrics) string { return formatSeconds(m.UpstreamWait) },
+	},
+	{
+		name:  "goio_stream_downstream_wait_seconds_total",
+		help:  "Time spent waiting for the downstream to request elements.",
+		value: func(m StageMetrics) string { return formatSeconds(m.DownstreamWait) },
+	},
+}
+
+// formatPrometheus renders metrics in Prometheus text exposition format.
+// Stages are sorted by name.
+func formatPrometheus(snapshot map[string]StageMetrics) string {
+	stages := make([]string, 0, len(snapshot))
+	for stage := range snapshot {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+	var sb strings.Builder
+	for _, pm := range prometheusMetrics {
+		fmt.Fprintf(&sb, "# HELP %s %s\n", pm.name, pm.help)
+		fmt.Fprintf(&sb, "# TYPE %s counter\n", pm.name)
+		for _, stage := range stages {
+			fmt.Fprintf(&sb, "%s{stage=\"%s\"} %s\n", pm.name, escapeLabelValue(stage), pm.value(snapshot[stage]))
+		}
+	}
+	return sb.String()
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue escapes backslash, double quote and line feed.
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
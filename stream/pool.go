@@ -2,7 +2,6 @@ package stream
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"sync"
 	"sync/atomic"
@@ -17,34 +16,10 @@ import (
 
 // NewPool creates an execution pool that will execute tasks concurrently.
 // Simultaneously there could be as many as size executions.
+// Results are emitted as soon as they are ready.
+// See NewWorkerPool for details.
 func NewPool[A any](size int) io.IO[Pipe[io.IO[A], io.GoResult[A]]] {
-	return io.Pure(func() Pipe[io.IO[A], io.GoResult[A]] {
-		input := make(chan io.IO[A])
-		output := make(chan io.GoResult[A])
-		completedExecutorIds := make(chan int)
-		executor := func(id int) {
-			for i := range input {
-				fmt.Println("received task")
-				result := io.RunSync(i)
-				fmt.Println("executed task: ", result)
-				output <- result
-			}
-			completedExecutorIds <- id
-		}
-		// start executors
-		for i := 0; i < size; i++ {
-			go executor(i)
-		}
-		go func() {
-			for i := 0; i < size; i++ {
-				id := <-completedExecutorIds
-				fmt.Println("executor completed: ", id)
-			}
-			close(output)
-		}()
-		pool := PairOfChannelsToPipe(input, output)
-		return pool
-	})
+	return io.Lift(NewWorkerPool[A](PoolOptions{Size: size}))
 }
 
 // NewPoolFromExecutionContext creates an execution pool that will execute tasks concurrently.
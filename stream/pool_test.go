@@ -2,6 +2,7 @@ package stream_test
 
 import (
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -110,3 +111,48 @@ func TestFailedDataStreamThroughECUnord(t *testing.T) {
 	throu := stream.ThroughExecutionContextUnordered(failedStream, bec, 10)
 	UnsafeIOExpectError(t, errExpected, stream.DrainAll(throu))
 }
+
+func sleepTask(id int) io.IO[int] {
+	return io.SleepA(time.Duration(10-id%10)*time.Millisecond, id)
+}
+
+func TestWorkerPoolOrdered(t *testing.T) {
+	tasks := stream.Map(stream.Take(nats, 50), sleepTask)
+	results := stream.ThroughWorkerPool(tasks, stream.PoolOptions{Size: 10, Ordered: true})
+	assert.Equal(t, UnsafeStreamToSlice(t, stream.Take(nats, 50)), UnsafeStreamToSlice(t, results))
+}
+
+func TestWorkerPoolUnordered(t *testing.T) {
+	tasks := stream.Map(stream.Take(nats, 50), sleepTask)
+	results := stream.ThroughWorkerPool(tasks, stream.PoolOptions{Size: 10})
+	assert.ElementsMatch(t, UnsafeStreamToSlice(t, stream.Take(nats, 50)), UnsafeStreamToSlice(t, results))
+}
+
+func TestWorkerPoolUpstreamFailure(t *testing.T) {
+	for _, ordered := range []bool{false, true} {
+		tasks := stream.Map(natsAndThenFail, io.Lift[int])
+		pool := stream.NewWorkerPool[int](stream.PoolOptions{Size: 3, Ordered: ordered})
+		results := stream.ToSlice(pool(tasks))
+		UnsafeIOExpectError(t, errExpected, results)
+	}
+}
+
+func TestWorkerPoolTaskTimeout(t *testing.T) {
+	tasks := stream.LiftMany(io.Lift(1), io.SleepA(time.Second, 2), io.Lift(3))
+	pool := stream.NewWorkerPool[int](stream.PoolOptions{Size: 2, Ordered: true, TaskTimeout: 20 * time.Millisecond})
+	results := UnsafeStreamToSlice(t, pool(tasks))
+	assert.Equal(t, []io.GoResult[int]{
+		io.NewGoResult(1),
+		io.NewFailedGoResult[int](io.ErrorTimeout),
+		io.NewGoResult(3),
+	}, results)
+}
+
+func TestWorkerPoolCancellation(t *testing.T) {
+	var count int32
+	finalizer := io.FromPureEffect(func() { atomic.AddInt32(&count, 1) })
+	tasks := stream.OnFinalize(stream.Map(nats, io.Lift[int]), finalizer)
+	results := stream.ThroughWorkerPool(tasks, stream.PoolOptions{Size: 4, Ordered: true})
+	assert.Equal(t, []int{1, 2, 3}, UnsafeStreamToSlice(t, stream.Take(results, 3)))
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&count) == 1 }, time.Second, time.Millisecond)
+}
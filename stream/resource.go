@@ -0,0 +1,39 @@
+package stream
+
+import (
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/resource"
+)
+
+// Bracket acquires a value, uses it to construct a stream and
+// releases the value when that stream is no longer needed.
+// Release is executed exactly once when the stream finishes, fails or is
+// abandoned by a downstream operation like Take or Head.
+// If acquisition fails, the stream fails and nothing is released.
+func Bracket[A any, B any](acquire io.IO[A], release func(A) io.IOUnit, use func(A) Stream[B]) Stream[B] {
+	return FlatMap(Eval(acquire), func(a A) Stream[B] {
+		return OnFinalize(use(a), release(a))
+	})
+}
+
+// FromResource returns a stream of a single element - the resource value.
+// The resource is released when the stream is no longer needed.
+// In particular, `FlatMap(FromResource(res), use)` keeps the resource
+// open while the stream returned by `use` is being consumed.
+func FromResource[A any](res resource.Resource[A]) Stream[A] {
+	return Bracket(
+		io.IO[resource.Closable[A]](res),
+		func(cl resource.Closable[A]) io.IOUnit {
+			return cl.Close()
+		},
+		func(cl resource.Closable[A]) Stream[A] {
+			return Lift(cl.Value)
+		},
+	)
+}
+
+// UseResource constructs a stream that has access to the resource value.
+// The resource is released when the stream is no longer needed.
+func UseResource[A any, B any](res resource.Resource[A], use func(A) Stream[B]) Stream[B] {
+	return FlatMap(FromResource(res), use)
+}
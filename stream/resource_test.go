@@ -0,0 +1,71 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/resource"
+	"github.com/primetalk/goio/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+type counters struct {
+	acquired int
+	released int
+}
+
+func (c *counters) resource() resource.Resource[int] {
+	return resource.NewResource(
+		io.Pure(func() int {
+			c.acquired += 1
+			return c.acquired
+		}),
+		func(int) io.IOUnit {
+			return io.FromPureEffect(func() {
+				c.released += 1
+			})
+		},
+	)
+}
+
+func TestBracket(t *testing.T) {
+	c := counters{}
+	bracket := stream.Bracket(
+		io.IO[resource.Closable[int]](c.resource()),
+		func(cl resource.Closable[int]) io.IOUnit { return cl.Close() },
+		func(cl resource.Closable[int]) stream.Stream[int] {
+			assert.Equal(t, c.acquired-1, c.released)
+			return stream.Map(nats10, func(i int) int { return i * cl.Value })
+		},
+	)
+	assert.Equal(t, nats10Values, UnsafeStreamToSlice(t, bracket))
+	assert.Equal(t, counters{acquired: 1, released: 1}, c)
+
+	assert.Equal(t, []int{2, 4}, UnsafeStreamToSlice(t, stream.Take(bracket, 2)))
+	assert.Equal(t, counters{acquired: 2, released: 2}, c)
+}
+
+func TestFromResource(t *testing.T) {
+	c := counters{}
+	useNats := func(int) stream.Stream[int] { return nats }
+
+	assert.Equal(t, 1, UnsafeIO(t, stream.Head(stream.UseResource(c.resource(), useNats))))
+	assert.Equal(t, counters{acquired: 1, released: 1}, c)
+
+	failed := stream.UseResource(c.resource(), func(int) stream.Stream[int] { return natsAndThenFail })
+	UnsafeIOExpectError(t, errExpected, stream.DrainAll(failed))
+	assert.Equal(t, counters{acquired: 2, released: 2}, c)
+
+	full := stream.FlatMap(stream.FromResource(c.resource()), func(i int) stream.Stream[fun.Pair[int, int]] {
+		return stream.Map(nats10, func(j int) fun.Pair[int, int] {
+			return fun.NewPair(i, c.released)
+		})
+	})
+	pairs := UnsafeStreamToSlice(t, full)
+	assert.Equal(t, fun.NewPair(3, 2), pairs[9])
+	assert.Equal(t, counters{acquired: 3, released: 3}, c)
+
+	UnsafeIOExpectError(t, errExpected, stream.DrainAll(stream.FromResource(resource.Fail[int](errExpected))))
+	assert.Equal(t, counters{acquired: 3, released: 3}, c)
+}
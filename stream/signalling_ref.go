@@ -0,0 +1,94 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/primetalk/goio/io"
+)
+
+// SignallingRef is a mutable reference that notifies listeners about changes.
+// It's safe to use from multiple go routines.
+type SignallingRef[A any] interface {
+	// Get returns the current value.
+	Get() io.IO[A]
+	// Set replaces the current value and notifies listeners.
+	Set(a A) io.IOUnit
+	// Update atomically modifies the current value and notifies listeners.
+	Update(f func(A) A) io.IOUnit
+	// WaitUntil blocks until the value satisfies the predicate and returns that value.
+	WaitUntil(predicate func(A) bool) io.IO[A]
+	// Discrete returns the stream of the current value followed by all subsequent changes.
+	// NB! A slow consumer might miss intermediate values.
+	Discrete() Stream[A]
+}
+
+type signallingRefImpl[A any] struct {
+	mu      sync.Mutex
+	value   A
+	changed chan struct{} // closed and replaced on each change
+}
+
+// NewSignallingRef creates a new reference with the given initial value.
+func NewSignallingRef[A any](a A) io.IO[SignallingRef[A]] {
+	return io.Pure(func() SignallingRef[A] {
+		return &signallingRefImpl[A]{
+			value:   a,
+			changed: make(chan struct{}),
+		}
+	})
+}
+
+func (r *signallingRefImpl[A]) Get() io.IO[A] {
+	return io.Pure(func() A {
+		a, _ := r.current()
+		return a
+	})
+}
+
+func (r *signallingRefImpl[A]) Set(a A) io.IOUnit {
+	return r.Update(func(A) A { return a })
+}
+
+func (r *signallingRefImpl[A]) Update(f func(A) A) io.IOUnit {
+	return io.FromPureEffect(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.value = f(r.value)
+		close(r.changed)
+		r.changed = make(chan struct{})
+	})
+}
+
+func (r *signallingRefImpl[A]) WaitUntil(predicate func(A) bool) io.IO[A] {
+	return io.Pure(func() A {
+		for {
+			a, changed := r.current()
+			if predicate(a) {
+				return a
+			}
+			<-changed
+		}
+	})
+}
+
+func (r *signallingRefImpl[A]) Discrete() Stream[A] {
+	return r.discreteFrom(nil)
+}
+
+// discreteFrom emits the current value as soon as the previous one has changed.
+func (r *signallingRefImpl[A]) discreteFrom(previous chan struct{}) Stream[A] {
+	return FromStepResult(io.Pure(func() StepResult[A] {
+		if previous != nil {
+			<-previous
+		}
+		a, changed := r.current()
+		return NewStepResult(a, r.discreteFrom(changed))
+	}))
+}
+
+// current returns the value together with the channel that will be closed on the next change.
+func (r *signallingRefImpl[A]) current() (A, chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.value, r.changed
+}
@@ -14,13 +14,23 @@ import (
 type Stream[A any] io.IO[StepResult[A]]
 
 // StepResult[A] represents the result of a single step in the step machine.
-// It might be one of - empty, new value, or finished.
+// It might be one of - empty, new value, chunk of values, or finished.
 // The step result also returns the continuation of the stream.
 type StepResult[A any] struct {
-	Value        A
-	HasValue     bool // models "Option[A]"
+	Value    A
+	HasValue bool // models "Option[A]"
+	// Chunk contains a few values that are delivered in a single step.
+	// When it's not empty, Value and HasValue are not used.
+	// Chunks reduce per-element overhead.
+	// Use StepResultMatch or StreamMatch to handle chunks element-by-element.
+	Chunk        []A
 	Continuation Stream[A]
 	IsFinished   bool // true when stream has completed
+	// Finalizer releases whatever is held by the Continuation.
+	// It should be executed by a consumer that abandons the Continuation
+	// before the stream has completed (like Take does).
+	// nil means that there is nothing to release.
+	Finalizer io.IOUnit
 }
 
 // NewStepResult constructs StepResult that has one value.
@@ -57,6 +67,7 @@ func StepResultMatch[A any, B any](sra StepResult[A],
 	onValue func(a A, continuation Stream[A]) B,
 	onEmpty func(continuation Stream[A]) B,
 ) (b B) {
+	sra = uncons(sra)
 	if sra.IsFinished {
 		b = onFinish()
 	} else if sra.HasValue {
@@ -78,6 +89,7 @@ func StreamMatch[A any, B any](
 	return io.Fold(
 		io.IO[StepResult[A]](stm),
 		func(sra StepResult[A]) (iores io.IO[B]) {
+			sra = uncons(sra)
 			if sra.IsFinished {
 				iores = onFinish()
 			} else {
@@ -105,26 +117,44 @@ func LazyFinishedStepResult[A any]() io.IO[StepResult[A]] {
 
 // MapEval maps the values of the stream. The provided function returns an IO.
 func MapEval[A any, B any](stm Stream[A], f func(a A) io.IO[B]) Stream[B] {
-	return Stream[B](StreamMatch(stm,
-		LazyFinishedStepResult[B],
-		func(a A, tail Stream[A]) io.IO[StepResult[B]] {
-			iob := f(a)
-			return io.Map(iob, func(b B) StepResult[B] {
-				return NewStepResult(b, MapEval(tail, f))
-			})
-		},
-		func(tail Stream[A]) io.IO[StepResult[B]] {
-			return io.Lift(
-				NewStepResultEmpty(MapEval(tail, f)),
-			)
-		},
-		io.Fail[StepResult[B]],
-	))
+	return Stream[B](io.FlatMap(
+		io.IO[StepResult[A]](stm),
+		func(sra StepResult[A]) io.IO[StepResult[B]] {
+			sra = uncons(sra)
+			if sra.IsFinished {
+				return LazyFinishedStepResult[B]()
+			} else if sra.HasValue {
+				return io.Fold(f(sra.Value),
+					func(b B) io.IO[StepResult[B]] {
+						return io.Lift(inheritFinalizer(NewStepResult(b, MapEval(sra.Continuation, f)), sra))
+					},
+					func(err error) io.IO[StepResult[B]] {
+						return finalizeAndFail[A, StepResult[B]](sra, err)
+					},
+				)
+			} else {
+				return io.Lift(inheritFinalizer(NewStepResultEmpty(MapEval(sra.Continuation, f)), sra))
+			}
+		}))
 }
 
 // Map converts values of the stream.
+// Chunks are converted at once.
 func Map[A any, B any](stm Stream[A], f func(a A) B) Stream[B] {
-	return MapEval(stm, func(a A) io.IO[B] { return io.Lift(f(a)) })
+	return Stream[B](io.Map(
+		io.IO[StepResult[A]](stm),
+		func(sra StepResult[A]) (srb StepResult[B]) {
+			if sra.IsFinished {
+				srb = NewStepResultFinished[B]()
+			} else if len(sra.Chunk) > 0 {
+				srb = NewStepResultChunk(slice.Map(sra.Chunk, f), Map(sra.Continuation, f))
+			} else if sra.HasValue {
+				srb = NewStepResult(f(sra.Value), Map(sra.Continuation, f))
+			} else {
+				srb = NewStepResultEmpty(Map(sra.Continuation, f))
+			}
+			return inheritFinalizer(srb, sra)
+		}))
 }
 
 // MapPipe creates a pipe that maps one stream through the provided function.
@@ -149,23 +179,16 @@ func AndThen[A any](stm1 Stream[A], stm2 Stream[A]) Stream[A] {
 
 // AndThenLazy appends another stream. The other stream is constructed lazily.
 func AndThenLazy[A any](stm1 Stream[A], stm2 func() Stream[A]) Stream[A] {
-	return Stream[A](StreamMatch(
-		stm1,
-		func() io.IO[StepResult[A]] {
-			return io.IO[StepResult[A]](stm2())
-		},
-		func(a A, tail Stream[A]) io.IO[StepResult[A]] {
-			return io.Lift(
-				NewStepResult(a, AndThenLazy(tail, stm2)),
-			)
-		},
-		func(tail Stream[A]) io.IO[StepResult[A]] {
-			return io.Lift(
-				NewStepResultEmpty(AndThenLazy(tail, stm2)),
-			)
-		},
-		io.Fail[StepResult[A]],
-	))
+	return Stream[A](io.FlatMap(
+		io.IO[StepResult[A]](stm1),
+		func(sra StepResult[A]) io.IO[StepResult[A]] {
+			if sra.IsFinished {
+				return io.IO[StepResult[A]](stm2())
+			} else {
+				sra.Continuation = AndThenLazy(sra.Continuation, stm2)
+				return io.Lift(sra)
+			}
+		}))
 }
 
 // FlatMap constructs a new stream by concatenating all substreams, produced by f
@@ -174,14 +197,15 @@ func FlatMap[A any, B any](stm Stream[A], f func(a A) Stream[B]) Stream[B] {
 	return Stream[B](io.FlatMap(
 		io.IO[StepResult[A]](stm),
 		func(sra StepResult[A]) io.IO[StepResult[B]] {
+			sra = uncons(sra)
 			if sra.IsFinished {
 				return io.Lift(NewStepResultFinished[B]())
 			} else if sra.HasValue {
-				stmb1 := f(sra.Value)
+				stmb1 := attachFinalizer(f(sra.Value), sra.Finalizer, false)
 				stmb := AndThenLazy(stmb1, func() Stream[B] { return FlatMap(sra.Continuation, f) })
 				return io.IO[StepResult[B]](stmb)
 			} else {
-				return io.Lift(NewStepResultEmpty(FlatMap(sra.Continuation, f)))
+				return io.Lift(inheritFinalizer(NewStepResultEmpty(FlatMap(sra.Continuation, f)), sra))
 			}
 		}))
 }
@@ -192,13 +216,13 @@ func FoldToGoResult[A any](stm Stream[A]) Stream[io.GoResult[A]] {
 	return Stream[io.GoResult[A]](
 		io.Map(io.FoldToGoResult(io.IO[StepResult[A]](stm)), func(gra io.GoResult[StepResult[A]]) StepResult[io.GoResult[A]] {
 			if gra.Error == nil {
-				sra := gra.Value
+				sra := uncons(gra.Value)
 				if sra.IsFinished {
 					return NewStepResultFinished[io.GoResult[A]]()
 				} else if sra.HasValue {
-					return NewStepResult(io.NewGoResult(sra.Value), FoldToGoResult(gra.Value.Continuation))
+					return inheritFinalizer(NewStepResult(io.NewGoResult(sra.Value), FoldToGoResult(sra.Continuation)), sra)
 				} else {
-					return NewStepResultEmpty(FoldToGoResult(sra.Continuation))
+					return inheritFinalizer(NewStepResultEmpty(FoldToGoResult(sra.Continuation)), sra)
 				}
 			} else {
 				return NewStepResult(io.NewFailedGoResult[A](gra.Error), Empty[io.GoResult[A]]())
@@ -251,17 +275,24 @@ func StateFlatMapWithFinish[A any, B any, S any](stm Stream[A],
 	res := io.FlatMap(
 		io.IO[StepResult[A]](stm),
 		func(sra StepResult[A]) (iores io.IO[StepResult[B]]) {
+			sra = uncons(sra)
 			if sra.IsFinished {
 				iores = io.Lift(NewStepResultEmpty(onFinish(zero)))
 			} else if sra.HasValue {
 				iop := f(sra.Value, zero)
-				iores = io.FlatMap(iop, func(p fun.Pair[S, Stream[B]]) io.IO[StepResult[B]] {
-					st, stmb1 := p.V1, p.V2
-					stmb := AndThenLazy(stmb1, func() Stream[B] { return StateFlatMapWithFinish(sra.Continuation, st, f, onFinish) })
-					return io.IO[StepResult[B]](stmb)
-				})
+				iores = io.Fold(iop,
+					func(p fun.Pair[S, Stream[B]]) io.IO[StepResult[B]] {
+						st, stmb1 := p.V1, p.V2
+						stmb := AndThenLazy(attachFinalizer(stmb1, sra.Finalizer, false),
+							func() Stream[B] { return StateFlatMapWithFinish(sra.Continuation, st, f, onFinish) })
+						return io.IO[StepResult[B]](stmb)
+					},
+					func(err error) io.IO[StepResult[B]] {
+						return finalizeAndFail[A, StepResult[B]](sra, err)
+					},
+				)
 			} else {
-				iores = io.Lift(NewStepResultEmpty(StateFlatMapWithFinish(sra.Continuation, zero, f, onFinish)))
+				iores = io.Lift(inheritFinalizer(NewStepResultEmpty(StateFlatMapWithFinish(sra.Continuation, zero, f, onFinish)), sra))
 			}
 			return
 		})
@@ -280,19 +311,25 @@ func StateFlatMapWithFinishAndFailureHandling[A any, B any, S any](stm Stream[A]
 	res := io.FlatMap(
 		io.IO[StepResult[A]](stm),
 		func(sra StepResult[A]) (iores io.IO[StepResult[B]]) {
+			sra = uncons(sra)
 			if sra.IsFinished {
 				iores = io.Lift(NewStepResultEmpty(onFinish(zero)))
 			} else if sra.HasValue {
 				iop := f(sra.Value, zero)
-				iores = io.FlatMap(iop, func(p fun.Pair[S, Stream[B]]) io.IO[StepResult[B]] {
-					st, stmb1 := p.V1, p.V2
-					stmb := AndThenLazy(stmb1, func() Stream[B] {
-						return StateFlatMapWithFinishAndFailureHandling(sra.Continuation, st, f, onFinish, onFailure)
-					})
-					return io.IO[StepResult[B]](stmb)
-				})
+				iores = io.Fold(iop,
+					func(p fun.Pair[S, Stream[B]]) io.IO[StepResult[B]] {
+						st, stmb1 := p.V1, p.V2
+						stmb := AndThenLazy(attachFinalizer(stmb1, sra.Finalizer, false), func() Stream[B] {
+							return StateFlatMapWithFinishAndFailureHandling(sra.Continuation, st, f, onFinish, onFailure)
+						})
+						return io.IO[StepResult[B]](stmb)
+					},
+					func(err error) io.IO[StepResult[B]] {
+						return finalizeAndFail[A, StepResult[B]](sra, err)
+					},
+				)
 			} else {
-				iores = io.Lift(NewStepResultEmpty(StateFlatMapWithFinishAndFailureHandling(sra.Continuation, zero, f, onFinish, onFailure)))
+				iores = io.Lift(inheritFinalizer(NewStepResultEmpty(StateFlatMapWithFinishAndFailureHandling(sra.Continuation, zero, f, onFinish, onFailure)), sra))
 			}
 			return
 		})
@@ -304,6 +341,7 @@ func StateFlatMapWithFinishAndFailureHandling[A any, B any, S any](stm Stream[A]
 }
 
 // Filter leaves in the stream only the elements that satisfy the given predicate.
+// Chunks are filtered at once.
 func Filter[A any](stm Stream[A], predicate func(A) bool) Stream[A] {
 	return Stream[A](io.Map(
 		io.IO[StepResult[A]](stm),
@@ -312,10 +350,12 @@ func Filter[A any](stm Stream[A], predicate func(A) bool) Stream[A] {
 				return sra
 			} else {
 				cont := Filter(sra.Continuation, predicate)
-				if sra.HasValue && predicate(sra.Value) {
-					return NewStepResult(sra.Value, cont)
+				if len(sra.Chunk) > 0 {
+					return inheritFinalizer(NewStepResultChunk(slice.Filter(sra.Chunk, predicate), cont), sra)
+				} else if sra.HasValue && predicate(sra.Value) {
+					return inheritFinalizer(NewStepResult(sra.Value, cont), sra)
 				} else {
-					return NewStepResultEmpty(cont)
+					return inheritFinalizer(NewStepResultEmpty(cont), sra)
 				}
 			}
 		}))
@@ -332,13 +372,9 @@ func FilterNot[A any](stm Stream[A], predicate func(A) bool) Stream[A] {
 // Sum is a pipe that returns a stream of 1 element that is sum of all elements of the original stream.
 func Sum[A fun.Number](sa Stream[A]) Stream[A] {
 	var zero A
-	return StateFlatMapWithFinish(sa, zero,
-		func(a A, s A) io.IO[fun.Pair[A, Stream[A]]] {
-			return io.Lift(fun.NewPair(s+a, Empty[A]()))
-		},
-		func(lastState A) Stream[A] {
-			return Lift(lastState)
-		})
+	return Eval(FoldLeft(sa, zero, func(s A, a A) A {
+		return s + a
+	}))
 }
 
 // Len is a pipe that returns a stream of 1 element that is the count of elements of the original stream.
@@ -504,12 +540,21 @@ func FoldLeftEval[A any, B any](stm Stream[A], zero B, combine func(B, A) io.IO[
 }
 
 // FoldLeft aggregates stream in a more simple way than StateFlatMap.
+// Chunks are aggregated at once.
 func FoldLeft[A any, B any](stm Stream[A], zero B, combine func(B, A) B) io.IO[B] {
-	return FoldLeftEval(stm, zero, func(b B, a A) io.IO[B] {
-		return io.Pure(func() B {
-			return combine(b, a)
+	return io.FlatMap(
+		io.IO[StepResult[A]](stm),
+		func(sra StepResult[A]) io.IO[B] {
+			if sra.IsFinished {
+				return io.Lift(zero)
+			} else if len(sra.Chunk) > 0 {
+				return FoldLeft(sra.Continuation, slice.FoldLeft(sra.Chunk, zero, combine), combine)
+			} else if sra.HasValue {
+				return FoldLeft(sra.Continuation, combine(zero, sra.Value), combine)
+			} else {
+				return FoldLeft(sra.Continuation, zero, combine)
+			}
 		})
-	})
 }
 
 // Wrapf wraps errors produced by this stream with additional context info.
@@ -520,16 +565,13 @@ func Wrapf[A any](stm Stream[A], format string, args ...interface{}) Stream[A] {
 		if sra.IsFinished {
 			res = io.Lift(sra)
 		} else {
-			cont := Stream[A](
+			cont := sra.Continuation
+			sra.Continuation = Stream[A](
 				io.Delay(func() io.IO[StepResult[A]] {
-					return io.IO[StepResult[A]](Wrapf(sra.Continuation, format, args...))
+					return io.IO[StepResult[A]](Wrapf(cont, format, args...))
 				}),
 			)
-			if sra.HasValue {
-				res = io.Lift(NewStepResult(sra.Value, cont))
-			} else {
-				res = io.Lift(NewStepResultEmpty(cont))
-			}
+			res = io.Lift(sra)
 		}
 		return
 	})
@@ -39,3 +39,35 @@ func BenchmarkForSum(b *testing.B) {
 		assert.Equal(b, 50005000, sum)
 	}
 }
+
+// elementSteps emits the slice element-by-element - one step per element.
+func elementSteps(as []int) stream.Stream[int] {
+	if len(as) == 0 {
+		return stream.Empty[int]()
+	}
+	return stream.Stream[int](io.Lift(stream.NewStepResult(as[0], elementSteps(as[1:]))))
+}
+
+// Both pipeline benchmarks use the same precomputed data,
+// so that only the effect of chunking is measured.
+var stepsRange10000 = elementSteps(range10000)
+
+var chunkedRange10000 = stream.FromSlice(range10000)
+
+func BenchmarkStreamPipeline(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		evens := stream.Filter(stream.Map(stepsRange10000, func(i int) int { return i * 2 }), isEven)
+		res, err1 := io.UnsafeRunSync(stream.Head(stream.Sum(evens)))
+		assert.NoError(b, err1)
+		assert.Equal(b, 100010000, res)
+	}
+}
+
+func BenchmarkChunkedStreamPipeline(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		evens := stream.Filter(stream.Map(chunkedRange10000, func(i int) int { return i * 2 }), isEven)
+		res, err1 := io.UnsafeRunSync(stream.Head(stream.Sum(evens)))
+		assert.NoError(b, err1)
+		assert.Equal(b, 100010000, res)
+	}
+}
@@ -29,13 +29,14 @@ func ToStreamEvent[A any](stm Stream[A]) Stream[StreamEvent[A]] {
 		io.Fold(
 			io.IO[StepResult[A]](stm),
 			func(sra StepResult[A]) io.IO[StepResult[StreamEvent[A]]] {
+				sra = uncons(sra)
 				var res StepResult[StreamEvent[A]]
 				if sra.IsFinished {
 					res = NewStepResult(NewStreamEventFinished[A](), Empty[StreamEvent[A]]())
 				} else if sra.HasValue {
-					res = NewStepResult(NewStreamEvent(sra.Value), ToStreamEvent(sra.Continuation))
+					res = inheritFinalizer(NewStepResult(NewStreamEvent(sra.Value), ToStreamEvent(sra.Continuation)), sra)
 				} else {
-					res = NewStepResultEmpty(ToStreamEvent(sra.Continuation))
+					res = inheritFinalizer(NewStepResultEmpty(ToStreamEvent(sra.Continuation)), sra)
 				}
 				return io.Lift(res)
 			},
@@ -46,3 +47,37 @@ func ToStreamEvent[A any](stm Stream[A]) Stream[StreamEvent[A]] {
 		),
 	)
 }
+
+// ToStreamEventChannel sends all elements of the stream to the channel
+// followed by the finish or error event. Then the channel is closed.
+// The IO blocks until the stream is exhausted.
+// The failure of the stream is delivered to the channel, so the IO itself doesn't fail.
+func ToStreamEventChannel[A any](stm Stream[A], ch chan<- StreamEvent[A]) io.IOUnit {
+	return ToChannel(ToStreamEvent(stm), ch)
+}
+
+// ToStreamEventChannels sends each stream element to every given channel
+// followed by the finish or error event. Then all channels are closed.
+// The failure of the stream is delivered to the channels, so the IO itself doesn't fail.
+func ToStreamEventChannels[A any](stm Stream[A], channels ...chan<- StreamEvent[A]) io.IOUnit {
+	return ToChannels(ToStreamEvent(stm), channels...)
+}
+
+// FromStreamEventChannel reads stream events from the channel until
+// the finish event, an error or closure of the channel.
+// The error event fails the stream.
+func FromStreamEventChannel[A any](ch <-chan StreamEvent[A]) Stream[A] {
+	return FromStepResult(
+		io.Eval(func() (sra StepResult[A], err error) {
+			e, ok := <-ch
+			if !ok || e.IsFinished {
+				sra = NewStepResultFinished[A]()
+			} else if e.Error != nil {
+				err = e.Error
+			} else {
+				sra = NewStepResult(e.Value, FromStreamEventChannel(ch))
+			}
+			return
+		}),
+	)
+}
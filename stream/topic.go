@@ -0,0 +1,218 @@
+package stream
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+)
+
+// ErrTopicClosed is returned when publishing to a topic that has been closed.
+var ErrTopicClosed = errors.New("topic is closed")
+
+// OverflowStrategy determines what happens when a subscriber's buffer is full.
+type OverflowStrategy int
+
+const (
+	// OverflowBackpressure makes the publisher wait until the subscriber has room.
+	OverflowBackpressure OverflowStrategy = iota
+	// OverflowDropNewest discards the element that doesn't fit into the buffer.
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest element in the buffer to make room for the new one.
+	OverflowDropOldest
+)
+
+// Topic is a publish-subscribe hub. Subscribers might join and leave at any time.
+// Each subscriber has it's own bounded buffer.
+type Topic[A any] interface {
+	// Publish sends the element to all current subscribers.
+	Publish(a A) io.IOUnit
+	// Subscribe registers a new subscriber and returns the stream of elements
+	// that are published afterwards.
+	// The subscriber leaves the topic when the stream is finalized
+	// (it completes, fails or is abandoned).
+	// A negative bufferSize is treated as 0 (unbuffered).
+	// NB! With OverflowBackpressure a subscriber that is not consumed blocks the publisher.
+	Subscribe(bufferSize int, overflow OverflowStrategy) io.IO[Stream[A]]
+	// Close completes streams of all subscribers.
+	Close() io.IOUnit
+	// Fail fails streams of all subscribers with the given error and closes the topic.
+	Fail(err error) io.IOUnit
+}
+
+type topicImpl[A any] struct {
+	publishMu   sync.Mutex // serializes publishing and closing
+	mu          sync.Mutex // protects the fields below
+	subscribers map[int]*subscriber[A]
+	nextID      int
+	closed      bool
+}
+
+type subscriber[A any] struct {
+	ch       chan StreamEvent[A]
+	overflow OverflowStrategy
+	done     chan struct{} // closed when the subscriber leaves
+	leave    sync.Once
+}
+
+// NewTopic creates a new topic without subscribers.
+func NewTopic[A any]() io.IO[Topic[A]] {
+	return io.Pure(func() Topic[A] {
+		return &topicImpl[A]{
+			subscribers: map[int]*subscriber[A]{},
+		}
+	})
+}
+
+func (t *topicImpl[A]) Publish(a A) io.IOUnit {
+	return io.FromUnit(func() error {
+		t.publishMu.Lock()
+		defer t.publishMu.Unlock()
+		subscribers, closed := t.snapshot(false)
+		if closed {
+			return ErrTopicClosed
+		}
+		for _, s := range subscribers {
+			s.send(NewStreamEvent(a))
+		}
+		return nil
+	})
+}
+
+func (t *topicImpl[A]) Subscribe(bufferSize int, overflow OverflowStrategy) io.IO[Stream[A]] {
+	return io.Pure(func() Stream[A] {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.closed {
+			return Empty[A]()
+		}
+		if bufferSize < 0 {
+			bufferSize = 0
+		}
+		s := &subscriber[A]{
+			ch:       make(chan StreamEvent[A], bufferSize),
+			overflow: overflow,
+			done:     make(chan struct{}),
+		}
+		id := t.nextID
+		t.nextID += 1
+		t.subscribers[id] = s
+		unsubscribe := io.FromPureEffect(func() {
+			s.leave.Do(func() { close(s.done) })
+			t.mu.Lock()
+			delete(t.subscribers, id)
+			t.mu.Unlock()
+		})
+		return OnFinalize(FromStreamEventChannel(s.ch), unsubscribe)
+	})
+}
+
+func (t *topicImpl[A]) Close() io.IOUnit {
+	return t.closeWith(NewStreamEventFinished[A]())
+}
+
+func (t *topicImpl[A]) Fail(err error) io.IOUnit {
+	return t.closeWith(NewStreamEventError[A](err))
+}
+
+// closeWith sends the last event to all subscribers and closes their channels.
+// It's a no-op for a closed topic.
+func (t *topicImpl[A]) closeWith(last StreamEvent[A]) io.IOUnit {
+	return io.FromPureEffect(func() {
+		t.publishMu.Lock()
+		defer t.publishMu.Unlock()
+		subscribers, closed := t.snapshot(true)
+		if !closed {
+			for _, s := range subscribers {
+				if last.Error != nil {
+					s.sendLast(last)
+				}
+				close(s.ch)
+			}
+		}
+	})
+}
+
+// snapshot returns the current subscribers and whether the topic has been closed before.
+func (t *topicImpl[A]) snapshot(closeTopic bool) (subscribers []*subscriber[A], closed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	closed = t.closed
+	if closeTopic {
+		t.closed = true
+	}
+	for _, s := range t.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	return
+}
+
+// send delivers the event according to the overflow strategy.
+func (s *subscriber[A]) send(e StreamEvent[A]) {
+	overflow := s.overflow
+	if cap(s.ch) == 0 {
+		overflow = OverflowBackpressure // there is no buffer to drop from
+	}
+	switch overflow {
+	case OverflowDropNewest:
+		select {
+		case s.ch <- e:
+		default:
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case s.ch <- e:
+				return
+			default:
+				select {
+				case <-s.ch:
+				default:
+				}
+			}
+		}
+	default:
+		select {
+		case s.ch <- e:
+		case <-s.done:
+		}
+	}
+}
+
+// sendLast delivers the final event even if the buffer is full.
+// The oldest elements are dropped in that case.
+func (s *subscriber[A]) sendLast(e StreamEvent[A]) {
+	if cap(s.ch) == 0 {
+		s.send(e)
+		return
+	}
+	for {
+		select {
+		case s.ch <- e:
+			return
+		case <-s.done:
+			return
+		default:
+			select {
+			case <-s.ch:
+			default:
+			}
+		}
+	}
+}
+
+// ToTopic publishes all elements of the stream to the topic.
+// When the stream completes, the topic is closed.
+// When the stream fails, the topic is failed with the same error.
+func ToTopic[A any](stm Stream[A], topic Topic[A]) io.IO[fun.Unit] {
+	return io.Fold(
+		DrainAll(MapEval(stm, topic.Publish)),
+		func(fun.Unit) io.IOUnit {
+			return topic.Close()
+		},
+		func(err error) io.IOUnit {
+			return io.AndThen(topic.Fail(err), io.Fail[fun.Unit](err))
+		},
+	)
+}
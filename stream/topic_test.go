@@ -0,0 +1,100 @@
+package stream_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/slice"
+	"github.com/primetalk/goio/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopic(t *testing.T) {
+	topic := UnsafeIO(t, stream.NewTopic[int]())
+	early := UnsafeIO(t, topic.Subscribe(10, stream.OverflowBackpressure))
+	UnsafeIO(t, topic.Publish(1))
+	late := UnsafeIO(t, topic.Subscribe(10, stream.OverflowBackpressure))
+	UnsafeIO(t, stream.ToTopic(stream.LiftMany(2, 3), topic))
+	assert.Equal(t, []int{1, 2, 3}, UnsafeStreamToSlice(t, early))
+	assert.Equal(t, []int{2, 3}, UnsafeStreamToSlice(t, late))
+
+	UnsafeIOExpectError(t, stream.ErrTopicClosed, topic.Publish(4))
+	assert.Empty(t, UnsafeStreamToSlice(t, UnsafeIO(t, topic.Subscribe(1, stream.OverflowBackpressure))))
+}
+
+func TestTopicNegativeBufferSize(t *testing.T) {
+	topic := UnsafeIO(t, stream.NewTopic[int]())
+	sub := UnsafeIO(t, topic.Subscribe(-1, stream.OverflowBackpressure))
+	publishing := UnsafeIO(t, io.Start(stream.ToTopic(stream.LiftMany(1, 2), topic)))
+	assert.Equal(t, []int{1, 2}, UnsafeStreamToSlice(t, sub))
+	UnsafeIO(t, publishing.Join())
+}
+
+func TestTopicFailure(t *testing.T) {
+	topic := UnsafeIO(t, stream.NewTopic[int]())
+	sub := UnsafeIO(t, topic.Subscribe(20, stream.OverflowBackpressure))
+	UnsafeIOExpectError(t, errExpected, stream.ToTopic(natsAndThenFail, topic))
+	results := []int{}
+	UnsafeIOExpectError(t, errExpected, stream.ForEach(sub, func(i int) {
+		results = append(results, i)
+	}))
+	assert.Equal(t, nats10Values, results)
+}
+
+func TestTopicOverflow(t *testing.T) {
+	topic := UnsafeIO(t, stream.NewTopic[int]())
+	newest := UnsafeIO(t, topic.Subscribe(3, stream.OverflowDropNewest))
+	oldest := UnsafeIO(t, topic.Subscribe(3, stream.OverflowDropOldest))
+	UnsafeIO(t, stream.ToTopic(nats10, topic))
+	assert.Equal(t, []int{1, 2, 3}, UnsafeStreamToSlice(t, newest))
+	assert.Equal(t, []int{8, 9, 10}, UnsafeStreamToSlice(t, oldest))
+}
+
+func TestTopicSubscriberLeaves(t *testing.T) {
+	topic := UnsafeIO(t, stream.NewTopic[int]())
+	sub := UnsafeIO(t, topic.Subscribe(0, stream.OverflowBackpressure))
+	publishing := UnsafeIO(t, io.Start(stream.ToTopic(nats, topic)))
+	assert.Equal(t, []int{1, 2, 3}, UnsafeStreamToSlice(t, stream.Take(sub, 3)))
+	// after the only subscriber has left, the publisher is not blocked anymore.
+	other := UnsafeIO(t, topic.Subscribe(0, stream.OverflowBackpressure))
+	assert.Len(t, UnsafeStreamToSlice(t, stream.Take(other, 2)), 2)
+	UnsafeIO(t, topic.Close())
+	UnsafeIOExpectError(t, stream.ErrTopicClosed, publishing.Join())
+}
+
+func TestBalance(t *testing.T) {
+	var mu sync.Mutex
+	counts := map[int]int{}
+	sums := UnsafeIO(t, stream.Balance(stream.Take(nats, 1000), 4, func(stm stream.Stream[int]) io.IO[int] {
+		return stream.Head(stream.Sum(stream.Map(stm, func(i int) int {
+			mu.Lock()
+			counts[i] += 1
+			mu.Unlock()
+			return i
+		})))
+	}))
+	assert.Len(t, sums, 4)
+	assert.Equal(t, 500500, slice.Sum(sums))
+	assert.Len(t, counts, 1000)
+	for _, c := range counts {
+		assert.Equal(t, 1, c)
+	}
+}
+
+func TestBalanceStops(t *testing.T) {
+	count := 0
+	finalizer := io.FromPureEffect(func() { count += 1 })
+	heads := UnsafeIO(t, stream.Balance(stream.OnFinalize(nats, finalizer), 2, stream.Head[int]))
+	assert.ElementsMatch(t, []int{1, 2}, heads)
+	assert.Equal(t, 1, count)
+
+	UnsafeIOExpectError(t, errExpected, stream.Balance(natsAndThenFail, 3, stream.DrainAll[int]))
+}
+
+func TestBalanceNonPositive(t *testing.T) {
+	sums := UnsafeIO(t, stream.Balance(nats10, 0, func(stm stream.Stream[int]) io.IO[int] {
+		return stream.Head(stream.Sum(stm))
+	}))
+	assert.Equal(t, []int{55}, sums)
+}
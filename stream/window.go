@@ -0,0 +1,287 @@
+package stream
+
+import (
+	"sort"
+	"time"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+)
+
+// Sliding splits the stream into windows.
+// Each window will have the given size.
+// The first window starts from the first element.
+// Each consecutive window starts step elements later.
+// Last window might very well be shorter.
+// It's the stream counterpart of slice.Sliding.
+func Sliding[A any](stm Stream[A], size int, step int) Stream[[]A] {
+	return StateFlatMapWithFinish(stm, slidingState[A]{},
+		func(a A, s slidingState[A]) io.IO[fun.Pair[slidingState[A], Stream[[]A]]] {
+			return io.Pure(func() fun.Pair[slidingState[A], Stream[[]A]] {
+				if s.skip > 0 {
+					s.skip -= 1
+					return fun.NewPair(s, Empty[[]A]())
+				}
+				s.buffer = append(s.buffer, a)
+				s.pending = true
+				if len(s.buffer) < size {
+					return fun.NewPair(s, Empty[[]A]())
+				}
+				window := append([]A{}, s.buffer...)
+				if step < size {
+					s.buffer = append([]A{}, s.buffer[step:]...)
+				} else {
+					s.buffer = nil
+					s.skip = step - size
+				}
+				s.pending = false
+				return fun.NewPair(s, Lift(window))
+			})
+		},
+		func(s slidingState[A]) Stream[[]A] {
+			if s.pending {
+				return Lift(s.buffer)
+			} else {
+				return Empty[[]A]()
+			}
+		},
+	)
+}
+
+// slidingState is the state of Sliding.
+type slidingState[A any] struct {
+	buffer  []A  // elements of the current window
+	skip    int  // number of elements to skip before the next window starts
+	pending bool // buffer contains elements that have not been emitted yet
+}
+
+// Window is a group of elements that belong to the same time interval [Start, End).
+type Window[A any] struct {
+	Start  time.Time
+	End    time.Time
+	Values []A
+}
+
+// TumblingWindow groups elements into non-overlapping windows of the given duration
+// according to the time when elements arrive.
+// NB! A window is emitted only when a later element arrives or when the stream finishes.
+func TumblingWindow[A any](stm Stream[A], d time.Duration) Stream[Window[A]] {
+	return Map(
+		TumblingWindowByEventTime(withArrivalTime(stm), d, fun.PairV1[time.Time, A], 0),
+		windowValuesV2[time.Time, A],
+	)
+}
+
+// TumblingWindowByEventTime groups elements into non-overlapping windows of the given duration
+// according to the event time of elements.
+// Elements might come out of order. A window is emitted when the maximum event time seen
+// minus allowedLateness (watermark) passes the end of the window.
+// Elements that belong to already emitted windows are dropped.
+// Windows are emitted in order of their start. Empty windows are not emitted.
+func TumblingWindowByEventTime[A any](stm Stream[A], d time.Duration,
+	eventTime func(A) time.Time,
+	allowedLateness time.Duration,
+) Stream[Window[A]] {
+	return Stream[Window[A]](io.Delay(func() io.IO[StepResult[Window[A]]] {
+		state := tumblingState[A]{
+			windows: map[int64]*Window[A]{},
+		}
+		return io.IO[StepResult[Window[A]]](StateFlatMapWithFinish(stm, &state,
+			func(a A, s *tumblingState[A]) io.IO[fun.Pair[*tumblingState[A], Stream[Window[A]]]] {
+				return io.Pure(func() fun.Pair[*tumblingState[A], Stream[Window[A]]] {
+					t := eventTime(a)
+					start := t.Truncate(d)
+					end := start.Add(d)
+					if !s.watermark.isLate(end) {
+						w, ok := s.windows[start.UnixNano()]
+						if !ok {
+							w = &Window[A]{Start: start, End: end}
+							s.windows[start.UnixNano()] = w
+						}
+						w.Values = append(w.Values, a)
+					}
+					s.watermark.observe(t, allowedLateness)
+					return fun.NewPair(s, LiftMany(s.closeWindows(false)...))
+				})
+			},
+			func(s *tumblingState[A]) Stream[Window[A]] {
+				return LiftMany(s.closeWindows(true)...)
+			},
+		))
+	}))
+}
+
+// tumblingState is the state of TumblingWindowByEventTime.
+type tumblingState[A any] struct {
+	windows   map[int64]*Window[A] // open windows by start
+	watermark watermark
+}
+
+// closeWindows removes and returns windows that are complete according to the watermark.
+func (s *tumblingState[A]) closeWindows(all bool) (closed []Window[A]) {
+	for start, w := range s.windows {
+		if all || s.watermark.isLate(w.End) {
+			closed = append(closed, *w)
+			delete(s.windows, start)
+		}
+	}
+	sortWindows(closed, func(w Window[A]) Window[A] { return w })
+	return
+}
+
+// SessionWindow groups elements with the same key into sessions according to the time when
+// elements arrive. A session is finished when there are no new elements with the key
+// during the gap.
+// NB! A session is emitted only when a later element arrives or when the stream finishes.
+func SessionWindow[A any, K comparable](stm Stream[A], key func(A) K, gap time.Duration) Stream[fun.Pair[K, Window[A]]] {
+	return Map(
+		SessionWindowByEventTime(withArrivalTime(stm),
+			func(p fun.Pair[time.Time, A]) K { return key(p.V2) },
+			fun.PairV1[time.Time, A],
+			gap, 0,
+		),
+		func(p fun.Pair[K, Window[fun.Pair[time.Time, A]]]) fun.Pair[K, Window[A]] {
+			return fun.NewPair(p.V1, windowValuesV2(p.V2))
+		},
+	)
+}
+
+// SessionWindowByEventTime groups elements with the same key into sessions according
+// to the event time of elements. Elements that are closer than gap to each other
+// belong to the same session. Window.End is the time of the last element plus gap.
+// Elements might come out of order. A session is emitted when the maximum event time seen
+// minus allowedLateness (watermark) passes the end of the session.
+// Late elements that would only form an already completed session are dropped.
+// Sessions are emitted in order of their start.
+func SessionWindowByEventTime[A any, K comparable](stm Stream[A], key func(A) K,
+	eventTime func(A) time.Time,
+	gap time.Duration,
+	allowedLateness time.Duration,
+) Stream[fun.Pair[K, Window[A]]] {
+	return Stream[fun.Pair[K, Window[A]]](io.Delay(func() io.IO[StepResult[fun.Pair[K, Window[A]]]] {
+		state := sessionState[A, K]{
+			sessions: map[K][]Window[A]{},
+		}
+		return io.IO[StepResult[fun.Pair[K, Window[A]]]](StateFlatMapWithFinish(stm, &state,
+			func(a A, s *sessionState[A, K]) io.IO[fun.Pair[*sessionState[A, K], Stream[fun.Pair[K, Window[A]]]]] {
+				return io.Pure(func() fun.Pair[*sessionState[A, K], Stream[fun.Pair[K, Window[A]]]] {
+					t := eventTime(a)
+					s.add(key(a), a, t, gap)
+					s.watermark.observe(t, allowedLateness)
+					return fun.NewPair(s, LiftMany(s.closeSessions(false)...))
+				})
+			},
+			func(s *sessionState[A, K]) Stream[fun.Pair[K, Window[A]]] {
+				return LiftMany(s.closeSessions(true)...)
+			},
+		))
+	}))
+}
+
+// sessionState is the state of SessionWindowByEventTime.
+type sessionState[A any, K comparable] struct {
+	sessions  map[K][]Window[A] // open sessions by key
+	watermark watermark
+}
+
+// add puts the element into a session. Sessions that become connected are merged.
+func (s *sessionState[A, K]) add(k K, a A, t time.Time, gap time.Duration) {
+	var merged *Window[A]
+	rest := []Window[A]{}
+	for _, w := range s.sessions[k] {
+		if !t.After(w.Start.Add(-gap)) || !t.Before(w.End) {
+			rest = append(rest, w)
+		} else if merged == nil {
+			first := w
+			merged = &first
+		} else {
+			if w.Start.Before(merged.Start) {
+				merged.Start = w.Start
+			}
+			if w.End.After(merged.End) {
+				merged.End = w.End
+			}
+			merged.Values = append(merged.Values, w.Values...)
+		}
+	}
+	if merged == nil {
+		merged = &Window[A]{Start: t, End: t.Add(gap)}
+		if s.watermark.isLate(merged.End) {
+			return
+		}
+	}
+	if t.Before(merged.Start) {
+		merged.Start = t
+	}
+	if t.Add(gap).After(merged.End) {
+		merged.End = t.Add(gap)
+	}
+	merged.Values = append(merged.Values, a)
+	s.sessions[k] = append(rest, *merged)
+}
+
+// closeSessions removes and returns sessions that are complete according to the watermark.
+func (s *sessionState[A, K]) closeSessions(all bool) (closed []fun.Pair[K, Window[A]]) {
+	for k, ws := range s.sessions {
+		open := []Window[A]{}
+		for _, w := range ws {
+			if all || s.watermark.isLate(w.End) {
+				closed = append(closed, fun.NewPair(k, w))
+			} else {
+				open = append(open, w)
+			}
+		}
+		if len(open) == 0 {
+			delete(s.sessions, k)
+		} else {
+			s.sessions[k] = open
+		}
+	}
+	sortWindows(closed, fun.PairV2[K, Window[A]])
+	return
+}
+
+// watermark tracks the maximum event time minus allowed lateness.
+type watermark struct {
+	time    time.Time
+	defined bool
+}
+
+// observe moves the watermark forward according to the event time.
+func (w *watermark) observe(t time.Time, allowedLateness time.Duration) {
+	wt := t.Add(-allowedLateness)
+	if !w.defined || wt.After(w.time) {
+		w.time = wt
+		w.defined = true
+	}
+}
+
+// isLate checks whether the given end of window has been passed by the watermark.
+func (w watermark) isLate(end time.Time) bool {
+	return w.defined && !end.After(w.time)
+}
+
+// sortWindows sorts by window start.
+func sortWindows[W any, A any](ws []W, window func(W) Window[A]) {
+	sort.SliceStable(ws, func(i, j int) bool {
+		return window(ws[i]).Start.Before(window(ws[j]).Start)
+	})
+}
+
+// withArrivalTime attaches the current time to each element.
+func withArrivalTime[A any](stm Stream[A]) Stream[fun.Pair[time.Time, A]] {
+	return MapEval(stm, func(a A) io.IO[fun.Pair[time.Time, A]] {
+		return io.Pure(func() fun.Pair[time.Time, A] {
+			return fun.NewPair(time.Now(), a)
+		})
+	})
+}
+
+// windowValuesV2 drops the first component of window values.
+func windowValuesV2[T any, A any](w Window[fun.Pair[T, A]]) Window[A] {
+	values := make([]A, 0, len(w.Values))
+	for _, p := range w.Values {
+		values = append(values, p.V2)
+	}
+	return Window[A]{Start: w.Start, End: w.End, Values: values}
+}
@@ -0,0 +1,87 @@
+package stream_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/slice"
+	"github.com/primetalk/goio/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSliding(t *testing.T) {
+	for _, sizeStep := range [][2]int{{3, 1}, {3, 2}, {2, 3}, {3, 3}, {20, 1}} {
+		size, step := sizeStep[0], sizeStep[1]
+		windows := UnsafeStreamToSlice(t, stream.Sliding(nats10, size, step))
+		assert.Equal(t, slice.Sliding(nats10Values, size, step), windows, "size=%d, step=%d", size, step)
+	}
+	assert.Empty(t, UnsafeStreamToSlice(t, stream.Sliding(stream.Empty[int](), 3, 1)))
+}
+
+var epoch = time.Unix(0, 0).UTC()
+
+func at(seconds int) time.Time {
+	return epoch.Add(time.Duration(seconds) * time.Second)
+}
+
+type event struct {
+	key     string
+	seconds int
+}
+
+func eventTime(e event) time.Time {
+	return at(e.seconds)
+}
+
+func eventSeconds(es []event) []int {
+	return slice.Map(es, func(e event) int { return e.seconds })
+}
+
+func TestTumblingWindowByEventTime(t *testing.T) {
+	events := stream.LiftMany(
+		event{"a", 1}, event{"a", 3}, event{"a", 12}, event{"a", 9},
+		event{"a", 25}, event{"a", 4}, event{"a", 31},
+	)
+	windows := UnsafeStreamToSlice(t, stream.TumblingWindowByEventTime(events, 10*time.Second, eventTime, 5*time.Second))
+	starts := slice.Map(windows, func(w stream.Window[event]) time.Time { return w.Start })
+	assert.Equal(t, []time.Time{at(0), at(10), at(20), at(30)}, starts)
+	values := slice.Map(windows, func(w stream.Window[event]) []int { return eventSeconds(w.Values) })
+	// 9 is within allowed lateness, 4 arrives after the first window has been emitted.
+	assert.Equal(t, [][]int{{1, 3, 9}, {12}, {25}, {31}}, values)
+	assert.Equal(t, at(10), windows[0].End)
+}
+
+func TestSessionWindowByEventTime(t *testing.T) {
+	events := stream.LiftMany(
+		event{"a", 1}, event{"b", 2}, event{"a", 3}, event{"a", 10},
+		event{"b", 6}, event{"a", 6}, event{"b", 30}, event{"a", 1},
+	)
+	sessions := UnsafeStreamToSlice(t, stream.SessionWindowByEventTime(events,
+		func(e event) string { return e.key }, eventTime, 5*time.Second, 5*time.Second))
+	values := slice.Map(sessions, func(p fun.Pair[string, stream.Window[event]]) fun.Pair[string, []int] {
+		return fun.NewPair(p.V1, eventSeconds(p.V2.Values))
+	})
+	// 6 joins sessions [1, 3] and [10]. The last 1 is too late.
+	assert.Equal(t, []fun.Pair[string, []int]{
+		fun.NewPair("a", []int{1, 3, 10, 6}),
+		fun.NewPair("b", []int{2, 6}),
+		fun.NewPair("b", []int{30}),
+	}, values)
+	assert.Equal(t, at(1), sessions[0].V2.Start)
+	assert.Equal(t, at(15), sessions[0].V2.End)
+}
+
+func TestProcessingTimeWindows(t *testing.T) {
+	windows := UnsafeStreamToSlice(t, stream.TumblingWindow(nats10, time.Hour))
+	assert.LessOrEqual(t, len(windows), 2)
+	assert.Equal(t, nats10Values, slice.Flatten(slice.Map(windows, func(w stream.Window[int]) []int { return w.Values })))
+
+	sessions := UnsafeStreamToSlice(t, stream.SessionWindow(nats10, isEven, time.Hour))
+	assert.Equal(t, []fun.Pair[bool, []int]{
+		fun.NewPair(false, []int{1, 3, 5, 7, 9}),
+		fun.NewPair(true, []int{2, 4, 6, 8, 10}),
+	}, slice.Map(sessions, func(p fun.Pair[bool, stream.Window[int]]) fun.Pair[bool, []int] {
+		return fun.NewPair(p.V1, p.V2.Values)
+	}))
+}
@@ -0,0 +1,180 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/option"
+)
+
+// PoolOptions configures a worker pool.
+type PoolOptions struct {
+	// Size is the number of workers. Values less than 1 mean a single worker.
+	Size int
+	// Ordered makes results appear in the order of tasks.
+	// Otherwise each result is emitted as soon as it's ready.
+	Ordered bool
+	// TaskTimeout limits the duration of each task (no limit when <= 0).
+	// A task that doesn't complete in time produces a result with io.ErrorTimeout.
+	TaskTimeout time.Duration
+}
+
+// NewWorkerPool creates a pipe that executes tasks on a fixed number of workers.
+// Failures of tasks are returned as failed GoResults.
+// When the stream of tasks fails, results of the started tasks are emitted
+// and then the result stream fails with the same error.
+// When the result stream is abandoned, workers stop taking new tasks
+// and the rest of the stream of tasks is finalized.
+// NB! Tasks that have already started run to completion in background.
+func NewWorkerPool[A any](options PoolOptions) Pipe[io.IO[A], io.GoResult[A]] {
+	return func(tasks Stream[io.IO[A]]) Stream[io.GoResult[A]] {
+		return Stream[io.GoResult[A]](io.Delay(func() io.IO[StepResult[io.GoResult[A]]] {
+			size := options.Size
+			if size < 1 {
+				size = 1
+			}
+			p := &workerPool[A]{
+				options: options,
+				jobs:    make(chan poolJob[A]),
+				results: make(chan io.GoResult[A]),
+				slots:   make(chan chan io.GoResult[A], size),
+				stop:    make(chan struct{}),
+			}
+			stopIO := io.FromPureEffect(func() {
+				p.stopOnce.Do(func() { close(p.stop) })
+			})
+			return io.AndThen(
+				io.FireAndForget(io.FromPureEffect(func() { p.run(tasks, size) })),
+				io.IO[StepResult[io.GoResult[A]]](OnFinalize(p.output(), stopIO)),
+			)
+		}))
+	}
+}
+
+// ThroughWorkerPool runs a stream of tasks through a worker pool.
+// The first failed task fails the result stream.
+func ThroughWorkerPool[A any](sa Stream[io.IO[A]], options PoolOptions) Stream[A] {
+	return UnfoldGoResult(NewWorkerPool[A](options)(sa), Fail[A])
+}
+
+// poolJob is a task together with the place for it's result.
+type poolJob[A any] struct {
+	task io.IO[A]
+	slot chan io.GoResult[A] // nil for unordered pools
+}
+
+// workerPool is the state of a single evaluation of NewWorkerPool.
+type workerPool[A any] struct {
+	options  PoolOptions
+	jobs     chan poolJob[A]
+	results  chan io.GoResult[A]      // results of an unordered pool
+	slots    chan chan io.GoResult[A] // result places of an ordered pool in the order of tasks
+	err      error                    // failure of the stream of tasks, set before closing results or slots
+	stop     chan struct{}            // closed when the result stream is finalized
+	stopOnce sync.Once
+}
+
+// run starts workers and feeds them with tasks until the stream of tasks
+// finishes or the pool is stopped.
+func (p *workerPool[A]) run(tasks Stream[io.IO[A]], size int) {
+	var wg sync.WaitGroup
+	wg.Add(size)
+	for i := 0; i < size; i++ {
+		go func() {
+			defer wg.Done()
+			p.work()
+		}()
+	}
+	p.err = p.feed(tasks)
+	close(p.jobs)
+	if p.options.Ordered {
+		close(p.slots)
+	} else {
+		wg.Wait()
+		close(p.results)
+	}
+}
+
+// feed sends tasks to workers. Returns the failure of the stream of tasks.
+func (p *workerPool[A]) feed(tasks Stream[io.IO[A]]) error {
+	cursor, _ := io.UnsafeRunSync(NewCursor(tasks))
+	defer io.UnsafeRunSync(cursor.Close())
+	for {
+		oioa, err := io.UnsafeRunSync(cursor.Next())
+		if err != nil {
+			return err
+		} else if option.IsEmpty(oioa) {
+			return nil
+		}
+		job := poolJob[A]{task: option.Get(oioa)}
+		if p.options.Ordered {
+			job.slot = make(chan io.GoResult[A], 1)
+			select {
+			case p.slots <- job.slot:
+			case <-p.stop:
+				return nil
+			}
+		}
+		select {
+		case p.jobs <- job:
+		case <-p.stop:
+			return nil
+		}
+	}
+}
+
+// work executes jobs until there are no more jobs or the pool is stopped.
+func (p *workerPool[A]) work() {
+	for {
+		select {
+		case job, ok := <-p.jobs:
+			if !ok {
+				return
+			}
+			task := job.task
+			if p.options.TaskTimeout > 0 {
+				task = io.WithTimeout[A](p.options.TaskTimeout)(task)
+			}
+			result := io.RunSync(task)
+			if job.slot != nil {
+				job.slot <- result
+			} else {
+				select {
+				case p.results <- result:
+				case <-p.stop:
+					return
+				}
+			}
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// output returns the stream of results.
+func (p *workerPool[A]) output() Stream[io.GoResult[A]] {
+	return FromStepResult(
+		io.Eval(func() (sr StepResult[io.GoResult[A]], err error) {
+			var result io.GoResult[A]
+			ok := true
+			if p.options.Ordered {
+				var slot chan io.GoResult[A]
+				slot, ok = <-p.slots
+				if ok {
+					result = <-slot
+				}
+			} else {
+				result, ok = <-p.results
+			}
+			if ok {
+				sr = NewStepResult(result, p.output())
+			} else if p.err != nil {
+				err = p.err
+			} else {
+				sr = NewStepResultFinished[io.GoResult[A]]()
+			}
+			return
+		}),
+	)
+}
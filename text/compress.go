@@ -0,0 +1,135 @@
+package text
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	fio "io"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/resource"
+	"github.com/primetalk/goio/stream"
+)
+
+// Gzip compresses byte chunks in gzip format with the default compression level.
+func Gzip(stm stream.Stream[[]byte]) stream.Stream[[]byte] {
+	return GzipLevel(gzip.DefaultCompression)(stm)
+}
+
+// GzipLevel compresses byte chunks in gzip format with the given compression level.
+func GzipLevel(level int) stream.Pipe[[]byte, []byte] {
+	return compressPipe(func(w fio.Writer) (fio.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, level)
+	})
+}
+
+// Gunzip decompresses gzip byte chunks.
+func Gunzip(stm stream.Stream[[]byte]) stream.Stream[[]byte] {
+	return decompressPipe(func(r fio.Reader) (fio.ReadCloser, error) {
+		return gzip.NewReader(r)
+	})(stm)
+}
+
+// ZlibCompress compresses byte chunks in zlib format with the given compression level.
+func ZlibCompress(level int) stream.Pipe[[]byte, []byte] {
+	return compressPipe(func(w fio.Writer) (fio.WriteCloser, error) {
+		return zlib.NewWriterLevel(w, level)
+	})
+}
+
+// ZlibDecompress decompresses zlib byte chunks.
+func ZlibDecompress(stm stream.Stream[[]byte]) stream.Stream[[]byte] {
+	return decompressPipe(zlib.NewReader)(stm)
+}
+
+// FlateCompress compresses byte chunks in raw deflate format with the given compression level.
+func FlateCompress(level int) stream.Pipe[[]byte, []byte] {
+	return compressPipe(func(w fio.Writer) (fio.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	})
+}
+
+// FlateDecompress decompresses raw deflate byte chunks.
+func FlateDecompress(stm stream.Stream[[]byte]) stream.Stream[[]byte] {
+	return decompressPipe(func(r fio.Reader) (fio.ReadCloser, error) {
+		return flate.NewReader(r), nil
+	})(stm)
+}
+
+// compressor writes compressed data to the buffer.
+type compressor struct {
+	buffer bytes.Buffer
+	writer fio.WriteCloser
+	closed bool
+}
+
+// take returns a copy of the compressed data that has been accumulated so far.
+func (c *compressor) take() []byte {
+	res := append([]byte{}, c.buffer.Bytes()...)
+	c.buffer.Reset()
+	return res
+}
+
+// close flushes the trailer. Subsequent calls do nothing.
+func (c *compressor) close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.writer.Close()
+}
+
+// compressPipe compresses byte chunks using the writer.
+// The writer is closed exactly once - either at the end of the stream when the trailer is emitted,
+// or when the stream is failed or abandoned.
+func compressPipe(newWriter func(fio.Writer) (fio.WriteCloser, error)) stream.Pipe[[]byte, []byte] {
+	return func(stm stream.Stream[[]byte]) stream.Stream[[]byte] {
+		newCompressor := io.Eval(func() (c *compressor, err error) {
+			c = &compressor{}
+			c.writer, err = newWriter(&c.buffer)
+			return
+		})
+		res := resource.NewResource(newCompressor, func(c *compressor) io.IOUnit {
+			return io.FromUnit(c.close)
+		})
+		return stream.UseResource(res, func(c *compressor) stream.Stream[[]byte] {
+			compressed := stream.MapEval(stm, func(chunk []byte) io.IO[[]byte] {
+				return io.Eval(func() ([]byte, error) {
+					_, err := c.writer.Write(chunk)
+					return c.take(), err
+				})
+			})
+			trailer := stream.Eval(io.Eval(func() ([]byte, error) {
+				err := c.close()
+				return c.take(), err
+			}))
+			return stream.Filter(stream.AndThen(compressed, trailer), isNotEmpty)
+		})
+	}
+}
+
+func isNotEmpty(chunk []byte) bool {
+	return len(chunk) > 0
+}
+
+// decompressPipe decompresses byte chunks using the reader.
+// The reader is closed and the rest of the original stream is finalized
+// when the result is no longer needed.
+func decompressPipe(newReader func(fio.Reader) (fio.ReadCloser, error)) stream.Pipe[[]byte, []byte] {
+	return func(stm stream.Stream[[]byte]) stream.Stream[[]byte] {
+		res := resource.FlatMap(stream.ToCursor(stm), func(cursor stream.Cursor[[]byte]) resource.Resource[fio.ReadCloser] {
+			return resource.NewResource(
+				io.Eval(func() (fio.ReadCloser, error) {
+					return newReader(&chunkReader{cursor: cursor})
+				}),
+				func(r fio.ReadCloser) io.IOUnit {
+					return io.FromUnit(r.Close)
+				},
+			)
+		})
+		return stream.UseResource(res, func(r fio.ReadCloser) stream.Stream[[]byte] {
+			return ReadByteChunks(r, DefaultChunkSize)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package text_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	fio "io"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+	"github.com/primetalk/goio/text"
+	"github.com/stretchr/testify/assert"
+)
+
+var compressibleText = []byte(strings.Repeat("the quick brown fox jumps over the lazy dog\n", 1000))
+
+func TestGzip(t *testing.T) {
+	compressed := concatBytes(t, text.Gzip(byteChunks(compressibleText, 100)))
+	assert.Less(t, len(compressed), len(compressibleText)/10)
+	// compatible with the standard library
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	assert.NoError(t, err)
+	plain, err := fio.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, compressibleText, plain)
+
+	decompressed := concatBytes(t, text.Gunzip(byteChunks(compressed, 7)))
+	assert.Equal(t, compressibleText, decompressed)
+
+	empty := concatBytes(t, text.Gunzip(text.Gzip(stream.Empty[[]byte]())))
+	assert.Empty(t, empty)
+}
+
+func TestZlibAndFlate(t *testing.T) {
+	zlibRoundTrip := text.ZlibDecompress(stream.Through(byteChunks(compressibleText, 100), text.ZlibCompress(flate.BestSpeed)))
+	assert.Equal(t, compressibleText, concatBytes(t, zlibRoundTrip))
+	flateRoundTrip := text.FlateDecompress(stream.Through(byteChunks(compressibleText, 100), text.FlateCompress(flate.BestCompression)))
+	assert.Equal(t, compressibleText, concatBytes(t, flateRoundTrip))
+}
+
+func TestGunzipFailure(t *testing.T) {
+	var count int32
+	finalizer := io.FromPureEffect(func() { atomic.AddInt32(&count, 1) })
+	notGzip := stream.OnFinalize(byteChunks([]byte("not a gzip stream"), 4), finalizer)
+	_, err := io.UnsafeRunSync(stream.ToSlice(text.Gunzip(notGzip)))
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	compressed := concatBytes(t, text.Gzip(byteChunks(compressibleText, 100)))
+	truncated := byteChunks(compressed[:len(compressed)/2], 10)
+	_, err = io.UnsafeRunSync(stream.ToSlice(text.Gunzip(truncated)))
+	assert.Error(t, err)
+}
@@ -0,0 +1,314 @@
+package text
+
+import (
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	fio "io"
+	"reflect"
+	"strconv"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+)
+
+// CSVOptions configures reading and writing of CSV.
+// Zero value corresponds to RFC 4180 without header.
+type CSVOptions struct {
+	// Comma is the field delimiter. ',' is used when it's zero.
+	Comma rune
+	// Comment, if not zero, is the character that starts a comment line.
+	Comment rune
+	// LazyQuotes allows a quote to appear in an unquoted field
+	// and a non-doubled quote to appear in a quoted field.
+	LazyQuotes bool
+	// TrimLeadingSpace ignores leading white space in a field.
+	TrimLeadingSpace bool
+	// Header means that the first record contains column names.
+	Header bool
+	// UseCRLF makes the writer use \r\n as the line terminator.
+	UseCRLF bool
+}
+
+// csvRecord is a record together with the line where it starts.
+type csvRecord struct {
+	line   int
+	fields []string
+}
+
+// ReadCSV reads CSV records from the reader.
+// Quoted fields might span multiple lines.
+// When options.Header is true, the first record is skipped.
+func ReadCSV(reader fio.Reader, options CSVOptions) stream.Stream[[]string] {
+	records := stream.Map(readCSVRecords(reader, options), func(r csvRecord) []string {
+		return r.fields
+	})
+	if options.Header {
+		return stream.Drop(records, 1)
+	} else {
+		return records
+	}
+}
+
+// ReadCSVStructs reads CSV records from the reader and decodes them into structs.
+// A field is mapped to the column with the name from it's `csv` tag or to the column with
+// the field's name when there is no tag. Fields with tag "-" are ignored.
+// When options.Header is true, columns are found by name in the header.
+// Otherwise, columns should be in the order of fields.
+// Supported field types are strings, booleans, numbers and types that implement encoding.TextUnmarshaler.
+// Empty values leave fields intact.
+func ReadCSVStructs[A any](reader fio.Reader, options CSVOptions) stream.Stream[A] {
+	return stream.Stream[A](io.Delay(func() io.IO[stream.StepResult[A]] {
+		fields, err := csvFields(reflect.TypeOf((*A)(nil)).Elem())
+		if err != nil {
+			return io.Fail[stream.StepResult[A]](err)
+		}
+		var columns []int
+		if !options.Header {
+			columns = csvFieldPositions(fields)
+		}
+		return io.IO[stream.StepResult[A]](stream.StateFlatMap(readCSVRecords(reader, options), columns,
+			func(r csvRecord, columns []int) io.IO[fun.Pair[[]int, stream.Stream[A]]] {
+				return io.Eval(func() (res fun.Pair[[]int, stream.Stream[A]], err error) {
+					if columns == nil {
+						columns, err = csvHeaderPositions(fields, r.fields)
+						res = fun.NewPair(columns, stream.Empty[A]())
+					} else {
+						var a A
+						a, err = decodeCSVStruct[A](fields, columns, r)
+						res = fun.NewPair(columns, stream.Lift(a))
+					}
+					return
+				})
+			},
+		))
+	}))
+}
+
+// WriteCSV creates a sink that writes CSV records to the writer.
+// Fields are quoted when needed.
+func WriteCSV(writer fio.Writer, options CSVOptions) stream.Sink[[]string] {
+	return func(stm stream.Stream[[]string]) stream.Stream[fun.Unit] {
+		return stream.Stream[fun.Unit](io.Delay(func() io.IO[stream.StepResult[fun.Unit]] {
+			w := csv.NewWriter(writer)
+			if options.Comma != 0 {
+				w.Comma = options.Comma
+			}
+			w.UseCRLF = options.UseCRLF
+			written := stream.MapEval(stm, func(record []string) io.IOUnit {
+				return io.FromUnit(func() error {
+					return w.Write(record)
+				})
+			})
+			flush := io.FromUnit(func() error {
+				w.Flush()
+				return w.Error()
+			})
+			return io.IO[stream.StepResult[fun.Unit]](stream.AndThen(written, stream.EvalEmpty[fun.Unit](flush)))
+		}))
+	}
+}
+
+// WriteCSVStructs creates a sink that encodes structs to CSV records and writes them to the writer.
+// Fields are mapped to columns in the same way as in ReadCSVStructs.
+// When options.Header is true, the header is written first (even for an empty stream).
+func WriteCSVStructs[A any](writer fio.Writer, options CSVOptions) stream.Sink[A] {
+	return func(stm stream.Stream[A]) stream.Stream[fun.Unit] {
+		fields, err := csvFields(reflect.TypeOf((*A)(nil)).Elem())
+		if err != nil {
+			return stream.Fail[fun.Unit](err)
+		}
+		records := stream.MapEval(stm, func(a A) io.IO[[]string] {
+			return io.Eval(func() ([]string, error) {
+				return encodeCSVStruct(fields, a)
+			})
+		})
+		if options.Header {
+			header := make([]string, 0, len(fields))
+			for _, f := range fields {
+				header = append(header, f.name)
+			}
+			records = stream.AndThen(stream.Lift(header), records)
+		}
+		return WriteCSV(writer, options)(records)
+	}
+}
+
+// readCSVRecords reads records using encoding/csv.
+func readCSVRecords(reader fio.Reader, options CSVOptions) stream.Stream[csvRecord] {
+	return stream.Stream[csvRecord](io.Delay(func() io.IO[stream.StepResult[csvRecord]] {
+		r := csv.NewReader(reader)
+		if options.Comma != 0 {
+			r.Comma = options.Comma
+		}
+		r.Comment = options.Comment
+		r.LazyQuotes = options.LazyQuotes
+		r.TrimLeadingSpace = options.TrimLeadingSpace
+		r.FieldsPerRecord = -1
+		return io.IO[stream.StepResult[csvRecord]](nextCSVRecord(r))
+	}))
+}
+
+func nextCSVRecord(r *csv.Reader) stream.Stream[csvRecord] {
+	return stream.FromStepResult(io.Eval(func() (res stream.StepResult[csvRecord], err error) {
+		fields, err1 := r.Read()
+		if err1 == fio.EOF {
+			res = stream.NewStepResultFinished[csvRecord]()
+		} else if err1 != nil {
+			err = err1
+		} else {
+			line, _ := r.FieldPos(0)
+			res = stream.NewStepResult(csvRecord{line: line, fields: fields}, nextCSVRecord(r))
+		}
+		return
+	}))
+}
+
+// csvField is a struct field that is mapped to a column.
+type csvField struct {
+	name  string
+	index int
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// csvFields finds fields of the struct type that are mapped to columns.
+func csvFields(t reflect.Type) (fields []csvField, err error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("csv: %v is not a struct", t)
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, hasTag := f.Tag.Lookup("csv")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if !hasTag || name == "" {
+			name = f.Name
+		}
+		if !isSupportedCSVType(f.Type) {
+			return nil, fmt.Errorf("csv: field %s has unsupported type %v", f.Name, f.Type)
+		}
+		fields = append(fields, csvField{name: name, index: i})
+	}
+	return
+}
+
+func isSupportedCSVType(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) && t.Implements(textMarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// csvFieldPositions maps fields to columns in order.
+func csvFieldPositions(fields []csvField) []int {
+	columns := make([]int, len(fields))
+	for i := range fields {
+		columns[i] = i
+	}
+	return columns
+}
+
+// csvHeaderPositions finds columns of fields in the header.
+func csvHeaderPositions(fields []csvField, header []string) ([]int, error) {
+	positions := map[string]int{}
+	for i, name := range header {
+		positions[name] = i
+	}
+	columns := make([]int, len(fields))
+	for i, f := range fields {
+		column, ok := positions[f.name]
+		if !ok {
+			return nil, fmt.Errorf("csv: column %q is not found in the header", f.name)
+		}
+		columns[i] = column
+	}
+	return columns, nil
+}
+
+func decodeCSVStruct[A any](fields []csvField, columns []int, r csvRecord) (a A, err error) {
+	v := reflect.ValueOf(&a).Elem()
+	for i, f := range fields {
+		if columns[i] >= len(r.fields) {
+			return a, fmt.Errorf("csv: line %d: column %q is missing", r.line, f.name)
+		}
+		s := r.fields[columns[i]]
+		if s == "" {
+			continue
+		}
+		err = setCSVValue(v.Field(f.index), s)
+		if err != nil {
+			return a, fmt.Errorf("csv: line %d: column %q: %w", r.line, f.name, err)
+		}
+	}
+	return
+}
+
+func encodeCSVStruct[A any](fields []csvField, a A) (record []string, err error) {
+	v := reflect.ValueOf(a)
+	record = make([]string, len(fields))
+	for i, f := range fields {
+		record[i], err = formatCSVValue(v.Field(f.index))
+		if err != nil {
+			return nil, fmt.Errorf("csv: field %q: %w", f.name, err)
+		}
+	}
+	return
+}
+
+func setCSVValue(v reflect.Value, s string) (err error) {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		i, err = strconv.ParseInt(s, 10, v.Type().Bits())
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(s, 10, v.Type().Bits())
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(s, v.Type().Bits())
+		v.SetFloat(f)
+	}
+	return
+}
+
+func formatCSVValue(v reflect.Value) (string, error) {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		bytes, err := m.MarshalText()
+		return string(bytes), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	default:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+}
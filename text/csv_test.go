@@ -0,0 +1,81 @@
+package text_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+	"github.com/primetalk/goio/text"
+	"github.com/stretchr/testify/assert"
+)
+
+const csvData = `name,age,comment
+alice,30,"likes ""quotes"""
+bob,,"multi
+line"
+`
+
+type person struct {
+	Name    string `csv:"name"`
+	Age     int    `csv:"age"`
+	Comment string `csv:"comment"`
+	Ignored string `csv:"-"`
+}
+
+func TestReadCSV(t *testing.T) {
+	records, err := io.UnsafeRunSync(stream.ToSlice(text.ReadCSV(strings.NewReader(csvData), text.CSVOptions{Header: true})))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"alice", "30", `likes "quotes"`},
+		{"bob", "", "multi\nline"},
+	}, records)
+
+	semicolons := text.ReadCSV(strings.NewReader("a;b\n"), text.CSVOptions{Comma: ';'})
+	records, err = io.UnsafeRunSync(stream.ToSlice(semicolons))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}}, records)
+
+	_, err = io.UnsafeRunSync(stream.ToSlice(text.ReadCSV(strings.NewReader("a,\"b\n"), text.CSVOptions{})))
+	assert.Error(t, err)
+}
+
+func TestReadCSVStructs(t *testing.T) {
+	people, err := io.UnsafeRunSync(stream.ToSlice(text.ReadCSVStructs[person](strings.NewReader(csvData), text.CSVOptions{Header: true})))
+	assert.NoError(t, err)
+	assert.Equal(t, []person{
+		{Name: "alice", Age: 30, Comment: `likes "quotes"`},
+		{Name: "bob", Comment: "multi\nline"},
+	}, people)
+
+	_, err = io.UnsafeRunSync(stream.ToSlice(text.ReadCSVStructs[person](strings.NewReader("carol,old,\n"), text.CSVOptions{})))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `line 1: column "age"`)
+	}
+	_, err = io.UnsafeRunSync(stream.ToSlice(text.ReadCSVStructs[person](strings.NewReader("name\n"), text.CSVOptions{Header: true})))
+	assert.Error(t, err)
+}
+
+func TestWriteCSV(t *testing.T) {
+	var sb strings.Builder
+	records := stream.LiftMany([]string{"a", "b,c"}, []string{"d\"e", "f"})
+	_, err := io.UnsafeRunSync(stream.DrainAll(stream.ToSink(records, text.WriteCSV(&sb, text.CSVOptions{}))))
+	assert.NoError(t, err)
+	assert.Equal(t, "a,\"b,c\"\n\"d\"\"e\",f\n", sb.String())
+}
+
+func TestWriteCSVStructsRoundTrip(t *testing.T) {
+	people := []person{
+		{Name: "alice", Age: 30, Comment: "multi\nline"},
+		{Name: "bob", Age: 40, Ignored: "x"},
+	}
+	var sb strings.Builder
+	options := text.CSVOptions{Header: true, Comma: ';'}
+	_, err := io.UnsafeRunSync(stream.DrainAll(stream.ToSink(stream.FromSlice(people), text.WriteCSVStructs[person](&sb, options))))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sb.String(), "name;age;comment\n"))
+	res, err := io.UnsafeRunSync(stream.ToSlice(text.ReadCSVStructs[person](strings.NewReader(sb.String()), options)))
+	assert.NoError(t, err)
+	people[1].Ignored = ""
+	assert.Equal(t, people, res)
+}
@@ -0,0 +1,166 @@
+package text
+
+import (
+	"encoding/binary"
+	"fmt"
+	fio "io"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+)
+
+// DecodeUTF8 converts UTF-8 byte chunks to strings.
+// A rune that is split across chunks is never split across strings.
+// Invalid bytes are kept as is.
+func DecodeUTF8(stm stream.Stream[[]byte]) stream.Stream[string] {
+	return stream.StateFlatMapWithFinish(stm, []byte{},
+		func(chunk []byte, rest []byte) io.IO[fun.Pair[[]byte, stream.Stream[string]]] {
+			return io.Pure(func() fun.Pair[[]byte, stream.Stream[string]] {
+				data := chunk
+				if len(rest) > 0 {
+					data = append(rest, chunk...)
+				}
+				n := completeUTF8Prefix(data)
+				restCopy := append([]byte{}, data[n:]...)
+				if n == 0 {
+					return fun.NewPair(restCopy, stream.Empty[string]())
+				} else {
+					return fun.NewPair(restCopy, stream.Lift(string(data[:n])))
+				}
+			})
+		},
+		func(rest []byte) stream.Stream[string] {
+			if len(rest) > 0 {
+				return stream.Lift(string(rest))
+			} else {
+				return stream.Empty[string]()
+			}
+		},
+	)
+}
+
+// completeUTF8Prefix returns the length of the prefix that doesn't end with an incomplete rune.
+func completeUTF8Prefix(data []byte) int {
+	i := len(data) - 1
+	for i >= 0 && len(data)-i < utf8.UTFMax && !utf8.RuneStart(data[i]) {
+		i -= 1
+	}
+	if i < 0 || utf8.FullRune(data[i:]) {
+		return len(data)
+	} else {
+		return i
+	}
+}
+
+// ReadRunes reads the UTF-8 encoded reader rune-by-rune.
+func ReadRunes(reader fio.Reader) stream.Stream[rune] {
+	strings := DecodeUTF8(ReadByteChunks(reader, DefaultChunkSize))
+	return stream.Unchunk(stream.Map(strings, func(s string) []rune { return []rune(s) }))
+}
+
+// utf16State is the state of UTF-16 decoding.
+type utf16State struct {
+	rest    []byte // bytes of an incomplete code unit or a high surrogate
+	order   binary.ByteOrder
+	started bool // byte order mark has been checked
+}
+
+// DecodeUTF16 converts UTF-16 encoded byte chunks to UTF-8.
+// A byte order mark at the beginning of the stream overrides the given order and is dropped.
+// Invalid code units are replaced with U+FFFD.
+func DecodeUTF16(order binary.ByteOrder) stream.Pipe[[]byte, []byte] {
+	return func(stm stream.Stream[[]byte]) stream.Stream[[]byte] {
+		return stream.StateFlatMapWithFinish(stm, utf16State{order: order},
+			func(chunk []byte, s utf16State) io.IO[fun.Pair[utf16State, stream.Stream[[]byte]]] {
+				return io.Pure(func() fun.Pair[utf16State, stream.Stream[[]byte]] {
+					data := append(append([]byte{}, s.rest...), chunk...)
+					if !s.started {
+						if len(data) < 2 {
+							s.rest = data
+							return fun.NewPair(s, stream.Empty[[]byte]())
+						}
+						s.started = true
+						if data[0] == 0xFE && data[1] == 0xFF {
+							s.order, data = binary.BigEndian, data[2:]
+						} else if data[0] == 0xFF && data[1] == 0xFE {
+							s.order, data = binary.LittleEndian, data[2:]
+						}
+					}
+					units := make([]uint16, 0, len(data)/2)
+					for i := 0; i+1 < len(data); i += 2 {
+						units = append(units, s.order.Uint16(data[i:]))
+					}
+					n := len(units) * 2
+					if len(units) > 0 && utf16.IsSurrogate(rune(units[len(units)-1])) && units[len(units)-1] < 0xDC00 {
+						units = units[:len(units)-1]
+						n -= 2
+					}
+					s.rest = data[n:]
+					return fun.NewPair(s, liftNonEmptyBytes([]byte(string(utf16.Decode(units)))))
+				})
+			},
+			func(s utf16State) stream.Stream[[]byte] {
+				if len(s.rest) > 0 {
+					return stream.Lift([]byte(string(utf8.RuneError)))
+				} else {
+					return stream.Empty[[]byte]()
+				}
+			},
+		)
+	}
+}
+
+// EncodeUTF16 converts UTF-8 byte chunks to UTF-16 with the given byte order.
+// Byte order mark is not written.
+func EncodeUTF16(order binary.ByteOrder) stream.Pipe[[]byte, []byte] {
+	return func(stm stream.Stream[[]byte]) stream.Stream[[]byte] {
+		return stream.Map(DecodeUTF8(stm), func(s string) []byte {
+			units := utf16.Encode([]rune(s))
+			bytes := make([]byte, len(units)*2)
+			for i, u := range units {
+				order.PutUint16(bytes[i*2:], u)
+			}
+			return bytes
+		})
+	}
+}
+
+// DecodeLatin1 converts ISO 8859-1 encoded byte chunks to UTF-8.
+func DecodeLatin1(stm stream.Stream[[]byte]) stream.Stream[[]byte] {
+	return stream.Map(stm, func(chunk []byte) []byte {
+		res := make([]byte, 0, len(chunk))
+		for _, b := range chunk {
+			res = utf8.AppendRune(res, rune(b))
+		}
+		return res
+	})
+}
+
+// EncodeLatin1 converts UTF-8 byte chunks to ISO 8859-1.
+// Runes that cannot be represented in ISO 8859-1 fail the stream.
+func EncodeLatin1(stm stream.Stream[[]byte]) stream.Stream[[]byte] {
+	return stream.MapEval(DecodeUTF8(stm), func(s string) io.IO[[]byte] {
+		return io.Eval(func() ([]byte, error) {
+			res := make([]byte, 0, len(s))
+			for _, r := range s {
+				if r > 0xFF {
+					return nil, fmt.Errorf("rune %q cannot be encoded in Latin-1", r)
+				}
+				res = append(res, byte(r))
+			}
+			return res, nil
+		})
+	})
+}
+
+// liftNonEmptyBytes returns a stream of a single chunk or an empty stream for an empty chunk.
+func liftNonEmptyBytes(bytes []byte) stream.Stream[[]byte] {
+	if len(bytes) == 0 {
+		return stream.Empty[[]byte]()
+	} else {
+		return stream.Lift(bytes)
+	}
+}
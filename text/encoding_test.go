@@ -0,0 +1,64 @@
+package text_test
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/slice"
+	"github.com/primetalk/goio/stream"
+	"github.com/primetalk/goio/text"
+	"github.com/stretchr/testify/assert"
+)
+
+const multiByteText = "naïve café — 日本語 🙂"
+
+// byteChunks splits the data into chunks of the given size.
+func byteChunks(data []byte, size int) stream.Stream[[]byte] {
+	return text.ReadByteChunks(strings.NewReader(string(data)), size)
+}
+
+func concatBytes(t *testing.T, stm stream.Stream[[]byte]) []byte {
+	chunks, err := io.UnsafeRunSync(stream.ToSlice(stm))
+	assert.NoError(t, err)
+	return slice.Flatten(chunks)
+}
+
+func TestDecodeUTF8(t *testing.T) {
+	for size := 1; size < 6; size++ {
+		strs, err := io.UnsafeRunSync(stream.ToSlice(text.DecodeUTF8(byteChunks([]byte(multiByteText), size))))
+		assert.NoError(t, err)
+		for _, s := range strs {
+			assert.True(t, strings.ToValidUTF8(s, "?") == s, "rune is split: %q", s)
+		}
+		assert.Equal(t, multiByteText, strings.Join(strs, ""))
+	}
+}
+
+func TestReadRunes(t *testing.T) {
+	runes, err := io.UnsafeRunSync(stream.ToSlice(text.ReadRunes(strings.NewReader(multiByteText))))
+	assert.NoError(t, err)
+	assert.Equal(t, []rune(multiByteText), runes)
+}
+
+func TestUTF16(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		encoded := concatBytes(t, stream.Through(byteChunks([]byte(multiByteText), 3), text.EncodeUTF16(order)))
+		decoded := concatBytes(t, stream.Through(byteChunks(encoded, 3), text.DecodeUTF16(order)))
+		assert.Equal(t, multiByteText, string(decoded))
+	}
+	withBOM := []byte{0xFF, 0xFE, 'h', 0, 'i', 0}
+	decoded := concatBytes(t, stream.Through(byteChunks(withBOM, 1), text.DecodeUTF16(binary.BigEndian)))
+	assert.Equal(t, "hi", string(decoded))
+}
+
+func TestLatin1(t *testing.T) {
+	latin1 := []byte{'c', 'a', 'f', 0xE9}
+	decoded := concatBytes(t, text.DecodeLatin1(byteChunks(latin1, 2)))
+	assert.Equal(t, "café", string(decoded))
+	encoded := concatBytes(t, text.EncodeLatin1(byteChunks(decoded, 4)))
+	assert.Equal(t, latin1, encoded)
+	_, err := io.UnsafeRunSync(stream.ToSlice(text.EncodeLatin1(byteChunks([]byte("日本"), 4))))
+	assert.Error(t, err)
+}
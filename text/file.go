@@ -1,11 +1,17 @@
 package text
 
 import (
+	"errors"
+	fio "io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/primetalk/goio/fun"
 	"github.com/primetalk/goio/io"
 	"github.com/primetalk/goio/resource"
+	"github.com/primetalk/goio/stream"
 )
 
 // ReadOnlyFile returns a resource for the specified filename.
@@ -21,3 +27,222 @@ func ReadOnlyFile(name string) resource.Resource[*os.File] {
 		},
 	)
 }
+
+// WriteFile returns a resource for a file that is opened for writing.
+// The file is created if needed and truncated.
+func WriteFile(name string, perm os.FileMode) resource.Resource[*os.File] {
+	return openFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+}
+
+// AppendFile returns a resource for a file that is opened for appending.
+// The file is created if needed.
+func AppendFile(name string, perm os.FileMode) resource.Resource[*os.File] {
+	return openFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, perm)
+}
+
+func openFile(name string, flag int, perm os.FileMode) resource.Resource[*os.File] {
+	return resource.NewResource(
+		io.Eval(func() (*os.File, error) {
+			return os.OpenFile(name, flag, perm)
+		}),
+		func(f *os.File) io.IO[fun.Unit] {
+			return io.FromUnit(func() error {
+				return f.Close()
+			})
+		},
+	)
+}
+
+// ToFile creates a sink that writes byte chunks to the file.
+// The file is truncated first and closed when the stream is no longer needed.
+func ToFile(name string, perm os.FileMode) stream.Sink[[]byte] {
+	return fileSink(WriteFile(name, perm))
+}
+
+// AppendToFile creates a sink that appends byte chunks to the file.
+// The file is closed when the stream is no longer needed.
+func AppendToFile(name string, perm os.FileMode) stream.Sink[[]byte] {
+	return fileSink(AppendFile(name, perm))
+}
+
+func fileSink(file resource.Resource[*os.File]) stream.Sink[[]byte] {
+	return func(stm stream.Stream[[]byte]) stream.Stream[fun.Unit] {
+		return stream.UseResource(file, func(f *os.File) stream.Stream[fun.Unit] {
+			return WriteByteChunks(f)(stm)
+		})
+	}
+}
+
+// ReadFileLines reads the file line-by-line.
+// The last line doesn't need to be terminated by '\n'.
+// The file is closed when the stream is no longer needed.
+func ReadFileLines(name string) stream.Stream[string] {
+	return stream.UseResource(ReadOnlyFile(name), func(f *os.File) stream.Stream[string] {
+		return ReadLinesWithNonFinishedLine(f)
+	})
+}
+
+// FileEntry is a file or a directory found by WalkDir.
+type FileEntry struct {
+	Path string
+	fs.DirEntry
+}
+
+var errWalkStopped = errors.New("walk stopped")
+
+// WalkDir returns all files and directories in the tree rooted at root (including root itself)
+// in lexical order. See filepath.WalkDir.
+// Directories are read lazily. The first error fails the stream.
+func WalkDir(root string) stream.Stream[FileEntry] {
+	return walkStream(func(fn fs.WalkDirFunc) error {
+		return filepath.WalkDir(root, fn)
+	})
+}
+
+// WalkFS returns all files and directories in the tree of the file system rooted at root.
+// See fs.WalkDir.
+func WalkFS(fsys fs.FS, root string) stream.Stream[FileEntry] {
+	return walkStream(func(fn fs.WalkDirFunc) error {
+		return fs.WalkDir(fsys, root, fn)
+	})
+}
+
+// walkStream runs the walk in a separate go routine.
+// When the stream is abandoned, the walk stops.
+func walkStream(walk func(fs.WalkDirFunc) error) stream.Stream[FileEntry] {
+	return stream.Stream[FileEntry](io.Delay(func() io.IO[stream.StepResult[FileEntry]] {
+		ch := make(chan stream.StreamEvent[FileEntry])
+		done := make(chan struct{})
+		send := func(e stream.StreamEvent[FileEntry]) bool {
+			select {
+			case ch <- e:
+				return true
+			case <-done:
+				return false
+			}
+		}
+		go func() {
+			defer close(ch)
+			err := walk(func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				} else if send(stream.NewStreamEvent(FileEntry{Path: path, DirEntry: d})) {
+					return nil
+				} else {
+					return errWalkStopped
+				}
+			})
+			if err != nil && err != errWalkStopped {
+				send(stream.NewStreamEventError[FileEntry](err))
+			}
+		}()
+		stop := io.FromPureEffect(func() { close(done) })
+		return io.IO[stream.StepResult[FileEntry]](stream.OnFinalize(stream.FromStreamEventChannel(ch), stop))
+	}))
+}
+
+// Tail follows a growing file and returns lines that are appended to it,
+// starting from the current end of the file.
+// When the file is truncated, reading starts from the beginning.
+// When the file is replaced (rotated), the rest of the old file is read and then the new file is followed.
+// The stream never finishes. Use Take or InterruptWhen to stop it.
+func Tail(name string, pollInterval time.Duration) stream.Stream[string] {
+	open := io.Eval(func() (t *tailer, err error) {
+		var f *os.File
+		f, err = os.Open(name)
+		if err == nil {
+			var offset int64
+			offset, err = f.Seek(0, fio.SeekEnd)
+			t = &tailer{name: name, file: f, offset: offset}
+		}
+		return
+	})
+	res := resource.NewResource(open, func(t *tailer) io.IOUnit {
+		return io.FromUnit(func() error {
+			return t.file.Close()
+		})
+	})
+	return stream.UseResource(res, func(t *tailer) stream.Stream[string] {
+		return t.lines(pollInterval)
+	})
+}
+
+// tailer is the state of Tail.
+type tailer struct {
+	name    string
+	file    *os.File
+	offset  int64
+	partial []byte // the last line that is not terminated yet
+}
+
+func (t *tailer) lines(pollInterval time.Duration) stream.Stream[string] {
+	return stream.FromStepResult(io.FlatMap(io.Eval(t.poll), func(lines []string) io.IO[stream.StepResult[string]] {
+		if len(lines) == 0 {
+			return io.AndThen(io.Sleep(pollInterval), io.Lift(stream.NewStepResultEmpty(t.lines(pollInterval))))
+		} else {
+			return io.Lift(stream.NewStepResultChunk(lines, t.lines(pollInterval)))
+		}
+	}))
+}
+
+// poll reads lines that have been appended since the previous poll.
+// When there are no new lines, it checks whether the file has been truncated or rotated.
+func (t *tailer) poll() (lines []string, err error) {
+	lines, err = t.readAvailable()
+	if err != nil || len(lines) > 0 {
+		return
+	}
+	var info, current fs.FileInfo
+	info, err = os.Stat(t.name)
+	if os.IsNotExist(err) {
+		return nil, nil // rotation is in progress
+	} else if err != nil {
+		return
+	}
+	current, err = t.file.Stat()
+	if err != nil {
+		return
+	}
+	if !os.SameFile(info, current) {
+		var f *os.File
+		f, err = os.Open(t.name)
+		if err != nil {
+			return
+		}
+		if len(t.partial) > 0 {
+			lines = append(lines, string(t.partial))
+		}
+		_ = t.file.Close()
+		t.file, t.offset, t.partial = f, 0, nil
+	} else if info.Size() < t.offset {
+		_, err = t.file.Seek(0, fio.SeekStart)
+		t.offset, t.partial = 0, nil
+	}
+	return
+}
+
+// readAvailable reads the file until the end and returns complete lines.
+func (t *tailer) readAvailable() (lines []string, err error) {
+	buf := make([]byte, DefaultChunkSize)
+	for {
+		var n int
+		n, err = t.file.Read(buf)
+		t.offset += int64(n)
+		data := buf[:n]
+		for {
+			i := indexOf('\n', data)
+			if i == -1 {
+				break
+			}
+			lines = append(lines, string(append(t.partial, data[:i]...)))
+			t.partial = nil
+			data = data[i+1:]
+		}
+		t.partial = append(t.partial, data...)
+		if err == fio.EOF {
+			return lines, nil
+		} else if err != nil {
+			return
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io/fs"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/primetalk/goio/fun"
 	"github.com/primetalk/goio/io"
@@ -71,3 +72,99 @@ func TestFile(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, content, str)
 }
+
+func TestFileStream(t *testing.T) {
+	path := t.TempDir() + "/lines.txt"
+	err := os.WriteFile(path, []byte(exampleText), fs.ModePerm)
+	assert.NoError(t, err)
+	var file *os.File
+	lines := stream.UseResource(text.ReadOnlyFile(path), func(f *os.File) stream.Stream[string] {
+		file = f
+		return text.ReadLines(f)
+	})
+	line2, err := io.UnsafeRunSync(stream.Head(stream.Drop(lines, 1)))
+	assert.NoError(t, err)
+	assert.Equal(t, "Line 2", line2)
+	_, err = file.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, fs.ErrClosed)
+}
+
+func TestWriteAndAppendFile(t *testing.T) {
+	path := t.TempDir() + "/out.txt"
+	write := func(sink stream.Sink[[]byte], content string) {
+		_, err := io.UnsafeRunSync(stream.DrainAll(stream.ToSink(stream.Lift([]byte(content)), sink)))
+		assert.NoError(t, err)
+	}
+	write(text.ToFile(path, 0644), "first\n")
+	write(text.AppendToFile(path, 0644), "second")
+	lines, err := io.UnsafeRunSync(stream.ToSlice(text.ReadFileLines(path)))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, lines)
+	write(text.ToFile(path, 0644), "third\n")
+	lines, err = io.UnsafeRunSync(stream.ToSlice(text.ReadFileLines(path)))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"third"}, lines)
+}
+
+func TestWalkDir(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(root+"/a/b", 0755))
+	assert.NoError(t, os.WriteFile(root+"/a/b/c.txt", []byte{}, 0644))
+	assert.NoError(t, os.WriteFile(root+"/d.txt", []byte{}, 0644))
+	paths := stream.Map(text.WalkDir(root), func(e text.FileEntry) string { return e.Path })
+	res, err := io.UnsafeRunSync(stream.ToSlice(paths))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{root, root + "/a", root + "/a/b", root + "/a/b/c.txt", root + "/d.txt"}, res)
+
+	first, err := io.UnsafeRunSync(stream.ToSlice(stream.Take(paths, 2)))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{root, root + "/a"}, first)
+
+	fsPaths := stream.Map(text.WalkFS(os.DirFS(root), "a"), func(e text.FileEntry) string { return e.Path })
+	res, err = io.UnsafeRunSync(stream.ToSlice(fsPaths))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "a/b", "a/b/c.txt"}, res)
+
+	_, err = io.UnsafeRunSync(stream.ToSlice(text.WalkDir(root + "/missing")))
+	assert.Error(t, err)
+}
+
+func TestTail(t *testing.T) {
+	path := t.TempDir() + "/app.log"
+	assert.NoError(t, os.WriteFile(path, []byte("old\n"), 0644))
+	appendLine := func(line string) io.IOUnit {
+		return io.FromUnit(func() error {
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+			if err == nil {
+				_, err = f.WriteString(line)
+				f.Close()
+			}
+			return err
+		})
+	}
+	// The first line is appended after Tail has opened the file.
+	// Further changes are made when the previous line has been received.
+	writer := io.AndThen(io.Sleep(100*time.Millisecond), appendLine("one\ntw"))
+	_, err := io.UnsafeRunSync(io.Start(writer))
+	assert.NoError(t, err)
+	onLine := map[string]io.IOUnit{
+		"one": appendLine("o\n"),
+		// truncation
+		"two": io.FromUnit(func() error { return os.WriteFile(path, []byte("three\n"), 0644) }),
+		// rotation
+		"three": io.AndThen(
+			io.FromUnit(func() error { return os.Rename(path, path+".1") }),
+			io.FromUnit(func() error { return os.WriteFile(path, []byte("four\n"), 0644) }),
+		),
+	}
+	tail := stream.SideEval(text.Tail(path, 5*time.Millisecond), func(line string) io.IOUnit {
+		if action, ok := onLine[line]; ok {
+			return action
+		} else {
+			return io.IOUnit1
+		}
+	})
+	lines, err := io.UnsafeRunSync(stream.ToSlice(stream.Take(tail, 4)))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "three", "four"}, lines)
+}
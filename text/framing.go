@@ -0,0 +1,141 @@
+package text
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+)
+
+// ErrTruncatedFrame is returned when the stream ends in the middle of a frame.
+var ErrTruncatedFrame = errors.New("truncated frame")
+
+// ErrFrameTooLarge is returned when the length of a frame exceeds the limit.
+var ErrFrameTooLarge = errors.New("frame is too large")
+
+// ErrInvalidPrefixSize is returned when the size of a length prefix is not 1, 2, 4 or 8.
+var ErrInvalidPrefixSize = errors.New("length prefix size should be 1, 2, 4 or 8")
+
+// SplitLengthPrefixed splits byte chunks into frames.
+// Each frame is preceded by it's length encoded as an unsigned integer
+// of prefixSize bytes (1, 2, 4 or 8) in the given byte order.
+// A frame longer than maxFrame fails the stream with ErrFrameTooLarge (no limit when <= 0).
+// When the stream ends in the middle of a frame, it fails with ErrTruncatedFrame.
+func SplitLengthPrefixed(stm stream.Stream[[]byte], prefixSize int, byteOrder binary.ByteOrder, maxFrame int) stream.Stream[[]byte] {
+	if !isValidPrefixSize(prefixSize) {
+		return stream.Fail[[]byte](fmt.Errorf("%w: %d", ErrInvalidPrefixSize, prefixSize))
+	}
+	return splitFrames(stm, func(buffer []byte) (prefix int, size int, err error) {
+		if len(buffer) < prefixSize {
+			return prefixSize, -1, nil
+		}
+		length := decodeLength(buffer[:prefixSize], byteOrder)
+		if (maxFrame > 0 && length > uint64(maxFrame)) || length > math.MaxInt {
+			return 0, 0, fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, length)
+		}
+		return prefixSize, int(length), nil
+	})
+}
+
+// FixedSizeFrames splits byte chunks into frames of n bytes each.
+// When the stream ends in the middle of a frame, it fails with ErrTruncatedFrame.
+func FixedSizeFrames(stm stream.Stream[[]byte], n int) stream.Stream[[]byte] {
+	if n <= 0 {
+		return stream.Fail[[]byte](fmt.Errorf("frame size should be positive: %d", n))
+	}
+	return splitFrames(stm, func(buffer []byte) (prefix int, size int, err error) {
+		return 0, n, nil
+	})
+}
+
+// AddLengthPrefix precedes each chunk with it's length encoded as an unsigned integer
+// of prefixSize bytes (1, 2, 4 or 8) in the given byte order.
+// This is the inverse of SplitLengthPrefixed.
+// A chunk that is too long for the prefix fails the stream with ErrFrameTooLarge.
+func AddLengthPrefix(prefixSize int, byteOrder binary.ByteOrder) stream.Pipe[[]byte, []byte] {
+	return func(stm stream.Stream[[]byte]) stream.Stream[[]byte] {
+		if !isValidPrefixSize(prefixSize) {
+			return stream.Fail[[]byte](fmt.Errorf("%w: %d", ErrInvalidPrefixSize, prefixSize))
+		}
+		return stream.MapEval(stm, func(frame []byte) io.IO[[]byte] {
+			return io.Eval(func() ([]byte, error) {
+				length := uint64(len(frame))
+				if prefixSize < 8 && length >= uint64(1)<<(8*prefixSize) {
+					return nil, fmt.Errorf("%w: %d bytes for %d-byte prefix", ErrFrameTooLarge, length, prefixSize)
+				}
+				res := make([]byte, prefixSize, prefixSize+len(frame))
+				encodeLength(res, length, byteOrder)
+				return append(res, frame...), nil
+			})
+		})
+	}
+}
+
+func isValidPrefixSize(prefixSize int) bool {
+	return prefixSize == 1 || prefixSize == 2 || prefixSize == 4 || prefixSize == 8
+}
+
+func decodeLength(prefix []byte, byteOrder binary.ByteOrder) uint64 {
+	switch len(prefix) {
+	case 1:
+		return uint64(prefix[0])
+	case 2:
+		return uint64(byteOrder.Uint16(prefix))
+	case 4:
+		return uint64(byteOrder.Uint32(prefix))
+	default:
+		return byteOrder.Uint64(prefix)
+	}
+}
+
+func encodeLength(prefix []byte, length uint64, byteOrder binary.ByteOrder) {
+	switch len(prefix) {
+	case 1:
+		prefix[0] = byte(length)
+	case 2:
+		byteOrder.PutUint16(prefix, uint16(length))
+	case 4:
+		byteOrder.PutUint32(prefix, uint32(length))
+	default:
+		byteOrder.PutUint64(prefix, length)
+	}
+}
+
+// splitFrames splits byte chunks into frames.
+// frameSize returns the size of the header and the size of the frame that starts at the beginning of the buffer.
+// When the header is not complete yet, size should be -1.
+// The state is the data that doesn't form a complete frame yet.
+// It's the unconsumed remainder of a single buffer that grows with amortised append,
+// so a large frame that is delivered in small chunks is not copied again and again.
+func splitFrames(stm stream.Stream[[]byte], frameSize func(buffer []byte) (prefix int, size int, err error)) stream.Stream[[]byte] {
+	return stream.StateFlatMapWithFinish(stm, []byte{},
+		func(a []byte, state []byte) io.IO[fun.Pair[[]byte, stream.Stream[[]byte]]] {
+			return io.Pure(func() fun.Pair[[]byte, stream.Stream[[]byte]] {
+				buffer := append(state, a...)
+				var frames [][]byte
+				for {
+					prefix, size, err := frameSize(buffer)
+					if err != nil {
+						return fun.NewPair([]byte{}, stream.AndThen(stream.FromSlice(frames), stream.Fail[[]byte](err)))
+					}
+					if size < 0 || len(buffer) < prefix+size {
+						break
+					}
+					frames = append(frames, append([]byte{}, buffer[prefix:prefix+size]...))
+					buffer = buffer[prefix+size:]
+				}
+				return fun.NewPair(buffer, stream.FromSlice(frames))
+			})
+		},
+		func(state []byte) stream.Stream[[]byte] {
+			if len(state) > 0 {
+				return stream.Fail[[]byte](fmt.Errorf("%w: %d bytes remaining", ErrTruncatedFrame, len(state)))
+			} else {
+				return emptyByteChunkStream
+			}
+		})
+}
@@ -0,0 +1,70 @@
+package text_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+	"github.com/primetalk/goio/text"
+	"github.com/stretchr/testify/assert"
+)
+
+var frames = [][]byte{[]byte("hello"), {}, []byte("binary"), []byte("framing")}
+
+func TestLengthPrefixedRoundTrip(t *testing.T) {
+	for _, prefixSize := range []int{1, 2, 4, 8} {
+		for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+			encoded := concatBytes(t, stream.Through(stream.FromSlice(frames), text.AddLengthPrefix(prefixSize, order)))
+			assert.Len(t, encoded, 18+4*prefixSize)
+			for size := 1; size < 6; size++ {
+				decoded, err := io.UnsafeRunSync(stream.ToSlice(text.SplitLengthPrefixed(byteChunks(encoded, size), prefixSize, order, 100)))
+				assert.NoError(t, err)
+				assert.Equal(t, frames, decoded)
+			}
+		}
+	}
+}
+
+func TestSplitLengthPrefixedErrors(t *testing.T) {
+	encoded := concatBytes(t, stream.Through(stream.FromSlice(frames), text.AddLengthPrefix(4, binary.BigEndian)))
+
+	decoded, err := io.UnsafeRunSync(stream.ToSlice(text.SplitLengthPrefixed(byteChunks(encoded[:len(encoded)-1], 3), 4, binary.BigEndian, 0)))
+	assert.ErrorIs(t, err, text.ErrTruncatedFrame)
+	assert.Empty(t, decoded)
+
+	_, err = io.UnsafeRunSync(stream.ToSlice(text.SplitLengthPrefixed(byteChunks(encoded[:2], 3), 4, binary.BigEndian, 0)))
+	assert.ErrorIs(t, err, text.ErrTruncatedFrame)
+
+	_, err = io.UnsafeRunSync(stream.ToSlice(text.SplitLengthPrefixed(byteChunks(encoded, 3), 4, binary.BigEndian, 6)))
+	assert.ErrorIs(t, err, text.ErrFrameTooLarge)
+
+	_, err = io.UnsafeRunSync(stream.ToSlice(text.SplitLengthPrefixed(byteChunks(encoded, 3), 3, binary.BigEndian, 0)))
+	assert.ErrorIs(t, err, text.ErrInvalidPrefixSize)
+
+	long := stream.Lift(make([]byte, 256))
+	_, err = io.UnsafeRunSync(stream.ToSlice(stream.Through(long, text.AddLengthPrefix(1, binary.BigEndian))))
+	assert.ErrorIs(t, err, text.ErrFrameTooLarge)
+}
+
+func TestFixedSizeFrames(t *testing.T) {
+	decoded, err := io.UnsafeRunSync(stream.ToSlice(text.FixedSizeFrames(byteChunks([]byte("abcdefghi"), 2), 3)))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("abc"), []byte("def"), []byte("ghi")}, decoded)
+
+	_, err = io.UnsafeRunSync(stream.ToSlice(text.FixedSizeFrames(byteChunks([]byte("abcdefgh"), 2), 3)))
+	assert.ErrorIs(t, err, text.ErrTruncatedFrame)
+}
+
+func TestSplitLengthPrefixedLargeFrame(t *testing.T) {
+	large := make([]byte, 16*1024*1024)
+	for i := range large {
+		large[i] = byte(i)
+	}
+	encoded := concatBytes(t, stream.Through(stream.LiftMany(large, []byte("next")), text.AddLengthPrefix(4, binary.BigEndian)))
+	decoded, err := io.UnsafeRunSync(stream.ToSlice(text.SplitLengthPrefixed(byteChunks(encoded, 4096), 4, binary.BigEndian, 0)))
+	assert.NoError(t, err)
+	assert.Len(t, decoded, 2)
+	assert.Equal(t, large, decoded[0])
+	assert.Equal(t, []byte("next"), decoded[1])
+}
@@ -0,0 +1,73 @@
+package text
+
+import (
+	"encoding/json"
+	"fmt"
+	fio "io"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+)
+
+// ReadJSONArray reads a top-level JSON array from the reader and emits it's elements one at a time.
+// The whole array is never loaded into memory.
+func ReadJSONArray[A any](reader fio.Reader) stream.Stream[A] {
+	return stream.Stream[A](io.Delay(func() io.IO[stream.StepResult[A]] {
+		dec := json.NewDecoder(reader)
+		return io.IO[stream.StepResult[A]](stream.AndThen(
+			stream.EvalEmpty[A](expectJSONDelim(dec, '[')),
+			readJSONArrayElements[A](dec),
+		))
+	}))
+}
+
+func readJSONArrayElements[A any](dec *json.Decoder) stream.Stream[A] {
+	return stream.FromStepResult(io.Delay(func() io.IO[stream.StepResult[A]] {
+		if dec.More() {
+			return io.Eval(func() (res stream.StepResult[A], err error) {
+				var a A
+				err = dec.Decode(&a)
+				res = stream.NewStepResult(a, readJSONArrayElements[A](dec))
+				return
+			})
+		} else {
+			return io.AndThen(
+				expectJSONDelim(dec, ']'),
+				io.Lift(stream.NewStepResultFinished[A]()),
+			)
+		}
+	}))
+}
+
+// expectJSONDelim reads the next token and checks that it's the given delimiter.
+func expectJSONDelim(dec *json.Decoder, delim json.Delim) io.IOUnit {
+	return io.FromUnit(func() error {
+		token, err := dec.Token()
+		if err == nil && token != delim {
+			err = fmt.Errorf("json array: expected %v, got %v at offset %d", delim, token, dec.InputOffset())
+		}
+		return err
+	})
+}
+
+var jsonArrayOpen = []byte{'['}
+var jsonArrayClose = []byte{']'}
+
+// WriteJSONArray creates a sink that writes all elements as a single JSON array.
+// An empty stream is written as [].
+func WriteJSONArray[A any](writer fio.Writer) stream.Sink[A] {
+	return func(stm stream.Stream[A]) stream.Stream[fun.Unit] {
+		elements := stream.MapEval(stream.ZipWithIndex(stm), func(p fun.Pair[int, A]) io.IO[[]byte] {
+			return io.Eval(func() ([]byte, error) {
+				bytes, err := json.Marshal(p.V2)
+				if p.V1 > 0 {
+					bytes = append([]byte{','}, bytes...)
+				}
+				return bytes, err
+			})
+		})
+		chunks := stream.AndThen(stream.AndThen(stream.Lift(jsonArrayOpen), elements), stream.Lift(jsonArrayClose))
+		return WriteByteChunks(writer)(chunks)
+	}
+}
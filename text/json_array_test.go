@@ -0,0 +1,43 @@
+package text_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+	"github.com/primetalk/goio/text"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadJSONArray(t *testing.T) {
+	input := ` [ {"id":1,"kind":"a"}, {"id":2,"kind":"b"} ] `
+	events, err := io.UnsafeRunSync(stream.ToSlice(text.ReadJSONArray[event](strings.NewReader(input))))
+	assert.NoError(t, err)
+	assert.Equal(t, []event{{ID: 1, Kind: "a"}, {ID: 2, Kind: "b"}}, events)
+
+	empty, err := io.UnsafeRunSync(stream.ToSlice(text.ReadJSONArray[event](strings.NewReader("[]"))))
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+
+	_, err = io.UnsafeRunSync(stream.ToSlice(text.ReadJSONArray[event](strings.NewReader(`{"id":1}`))))
+	assert.Error(t, err)
+
+	first, err := io.UnsafeRunSync(stream.Head(text.ReadJSONArray[int](strings.NewReader(`[1, 2, "truncated`))))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, first)
+	_, err = io.UnsafeRunSync(stream.ToSlice(text.ReadJSONArray[int](strings.NewReader(`[1, 2, "truncated`))))
+	assert.Error(t, err)
+}
+
+func TestWriteJSONArray(t *testing.T) {
+	write := func(events stream.Stream[event]) string {
+		var sb strings.Builder
+		_, err := io.UnsafeRunSync(stream.DrainAll(stream.ToSink(events, text.WriteJSONArray[event](&sb))))
+		assert.NoError(t, err)
+		return sb.String()
+	}
+	assert.Equal(t, "[]", write(stream.Empty[event]()))
+	assert.Equal(t, `[{"id":1,"kind":"a"},{"id":2,"kind":"b"}]`,
+		write(stream.LiftMany(event{ID: 1, Kind: "a"}, event{ID: 2, Kind: "b"})))
+}
@@ -0,0 +1,74 @@
+package text
+
+import (
+	"encoding/json"
+	"fmt"
+	fio "io"
+	"strings"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+)
+
+// JSONLineError describes a line that couldn't be decoded.
+type JSONLineError struct {
+	Line int    // line number starting from 1
+	Text string // the line itself
+	Err  error
+}
+
+func (e JSONLineError) Error() string {
+	return fmt.Sprintf("json lines: line %d: %v", e.Line, e.Err)
+}
+
+func (e JSONLineError) Unwrap() error {
+	return e.Err
+}
+
+// ReadJSONLines reads newline-delimited JSON values from the reader.
+// Blank lines are ignored. The last line doesn't need to be terminated by '\n'.
+// The first malformed line fails the stream with JSONLineError.
+func ReadJSONLines[A any](reader fio.Reader) stream.Stream[A] {
+	return readJSONLines[A](reader, func(e JSONLineError) io.IO[stream.Stream[A]] {
+		return io.Fail[stream.Stream[A]](e)
+	})
+}
+
+// ReadJSONLinesSkippingMalformed reads newline-delimited JSON values from the reader.
+// Malformed lines are sent to the given consumer and skipped.
+func ReadJSONLinesSkippingMalformed[A any](reader fio.Reader, malformed io.Consumer[JSONLineError]) stream.Stream[A] {
+	return readJSONLines[A](reader, func(e JSONLineError) io.IO[stream.Stream[A]] {
+		return io.AndThen(malformed(e), io.Lift(stream.Empty[A]()))
+	})
+}
+
+func readJSONLines[A any](reader fio.Reader, onError func(JSONLineError) io.IO[stream.Stream[A]]) stream.Stream[A] {
+	lines := stream.ZipWithIndex(ReadLinesWithNonFinishedLine(reader))
+	return stream.Flatten(stream.MapEval(lines, func(p fun.Pair[int, string]) io.IO[stream.Stream[A]] {
+		line := strings.TrimSuffix(p.V2, "\r")
+		if strings.TrimSpace(line) == "" {
+			return io.Lift(stream.Empty[A]())
+		}
+		var a A
+		err := json.Unmarshal([]byte(line), &a)
+		if err == nil {
+			return io.Lift(stream.Lift(a))
+		} else {
+			return onError(JSONLineError{Line: p.V1 + 1, Text: line, Err: err})
+		}
+	}))
+}
+
+// WriteJSONLines creates a sink that writes each element as a single line of JSON.
+func WriteJSONLines[A any](writer fio.Writer) stream.Sink[A] {
+	return func(stm stream.Stream[A]) stream.Stream[fun.Unit] {
+		lines := stream.MapEval(stm, func(a A) io.IO[[]byte] {
+			return io.Eval(func() ([]byte, error) {
+				bytes, err := json.Marshal(a)
+				return append(bytes, '\n'), err
+			})
+		})
+		return WriteByteChunks(writer)(lines)
+	}
+}
@@ -0,0 +1,47 @@
+package text_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+	"github.com/primetalk/goio/text"
+	"github.com/stretchr/testify/assert"
+)
+
+type event struct {
+	ID   int    `json:"id"`
+	Kind string `json:"kind"`
+}
+
+const jsonLines = "{\"id\":1,\"kind\":\"a\"}\r\n\n{\"id\":2,\n{\"id\":3,\"kind\":\"c\"}"
+
+func TestReadJSONLines(t *testing.T) {
+	_, err := io.UnsafeRunSync(stream.ToSlice(text.ReadJSONLines[event](strings.NewReader(jsonLines))))
+	var lineErr text.JSONLineError
+	if assert.True(t, errors.As(err, &lineErr)) {
+		assert.Equal(t, 3, lineErr.Line)
+		assert.Equal(t, "{\"id\":2,", lineErr.Text)
+	}
+}
+
+func TestReadJSONLinesSkippingMalformed(t *testing.T) {
+	malformed := []int{}
+	consumer := func(e text.JSONLineError) io.IOUnit {
+		return io.FromPureEffect(func() { malformed = append(malformed, e.Line) })
+	}
+	events, err := io.UnsafeRunSync(stream.ToSlice(text.ReadJSONLinesSkippingMalformed[event](strings.NewReader(jsonLines), consumer)))
+	assert.NoError(t, err)
+	assert.Equal(t, []event{{ID: 1, Kind: "a"}, {ID: 3, Kind: "c"}}, events)
+	assert.Equal(t, []int{3}, malformed)
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	var sb strings.Builder
+	events := stream.LiftMany(event{ID: 1, Kind: "a"}, event{ID: 2, Kind: "b"})
+	_, err := io.UnsafeRunSync(stream.DrainAll(stream.ToSink(events, text.WriteJSONLines[event](&sb))))
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"id\":1,\"kind\":\"a\"}\n{\"id\":2,\"kind\":\"b\"}\n", sb.String())
+}
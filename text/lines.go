@@ -0,0 +1,169 @@
+package text
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	fio "io"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+)
+
+// ErrLineTooLong is returned when a line is longer than LineReaderOptions.MaxLineLength.
+var ErrLineTooLong = errors.New("line is too long")
+
+// LineReaderOptions configures splitting of byte chunks into lines.
+// Zero value splits by '\n' and keeps the last line even if it's not terminated.
+type LineReaderOptions struct {
+	// Delimiter separates lines. "\n" is used when it's empty.
+	Delimiter string
+	// StripCR removes '\r' at the end of each line. This allows reading files with "\r\n" line endings.
+	StripCR bool
+	// DropUnterminatedLastLine ignores the last line if it's not terminated by the delimiter.
+	DropUnterminatedLastLine bool
+	// MaxLineLength limits the length of a line in bytes (no limit when <= 0).
+	// With StripCR the stripped '\r' is not counted.
+	// A longer line fails the stream with ErrLineTooLong.
+	MaxLineLength int
+	// TruncateLongLines makes lines longer than MaxLineLength to be truncated instead of failing the stream.
+	TruncateLongLines bool
+}
+
+// Line is a line of text together with it's number starting from 1.
+type Line struct {
+	Number int
+	Text   string
+}
+
+// ReadLinesWithOptions reads lines from the reader according to the options.
+// Memory usage is bounded when MaxLineLength is set.
+func ReadLinesWithOptions(reader fio.Reader, options LineReaderOptions) stream.Stream[Line] {
+	return SplitLines(ReadByteChunks(reader, DefaultChunkSize), options)
+}
+
+// SplitLines splits byte chunks into lines according to the options.
+func SplitLines(stm stream.Stream[[]byte], options LineReaderOptions) stream.Stream[Line] {
+	return stream.Stream[Line](io.Delay(func() io.IO[stream.StepResult[Line]] {
+		delimiter := []byte(options.Delimiter)
+		if len(delimiter) == 0 {
+			delimiter = endline
+		}
+		s := &lineSplitter{options: options, delimiter: delimiter}
+		return io.IO[stream.StepResult[Line]](stream.StateFlatMapWithFinish(stm, s,
+			func(chunk []byte, s *lineSplitter) io.IO[fun.Pair[*lineSplitter, stream.Stream[Line]]] {
+				return io.Pure(func() fun.Pair[*lineSplitter, stream.Stream[Line]] {
+					lines, err := s.split(chunk)
+					return fun.NewPair(s, linesOrFail(lines, err))
+				})
+			},
+			func(s *lineSplitter) stream.Stream[Line] {
+				return linesOrFail(s.finish())
+			},
+		))
+	}))
+}
+
+// lineSplitter is the state of SplitLines.
+type lineSplitter struct {
+	options   LineReaderOptions
+	delimiter []byte
+	line      []byte // content of the current line (at most MaxLineLength bytes)
+	carry     []byte // the end of the previous chunk that might be the beginning of a delimiter
+	number    int    // number of lines emitted so far
+}
+
+// split returns lines that are completed by the chunk.
+func (s *lineSplitter) split(chunk []byte) (lines []Line, err error) {
+	data := chunk
+	if len(s.carry) > 0 {
+		data = append(s.carry, chunk...)
+	}
+	s.carry = nil
+	for {
+		i := bytes.Index(data, s.delimiter)
+		if i == -1 {
+			break
+		}
+		err = s.appendToLine(data[:i])
+		if err != nil {
+			return
+		}
+		var line Line
+		line, err = s.emit()
+		if err != nil {
+			return
+		}
+		lines = append(lines, line)
+		data = data[i+len(s.delimiter):]
+	}
+	keep := len(s.delimiter) - 1
+	if keep > len(data) {
+		keep = len(data)
+	}
+	err = s.appendToLine(data[:len(data)-keep])
+	s.carry = append([]byte{}, data[len(data)-keep:]...)
+	return
+}
+
+// finish returns the last unterminated line if needed.
+func (s *lineSplitter) finish() (lines []Line, err error) {
+	err = s.appendToLine(s.carry)
+	if err == nil && len(s.line) > 0 && !s.options.DropUnterminatedLastLine {
+		var line Line
+		line, err = s.emit()
+		if err == nil {
+			lines = append(lines, line)
+		}
+	}
+	return
+}
+
+// appendToLine adds the data to the current line respecting the maximum line length.
+// With StripCR one extra byte is kept for the '\r' that is removed in emit.
+func (s *lineSplitter) appendToLine(data []byte) error {
+	limit := s.options.MaxLineLength
+	if limit > 0 && s.options.StripCR {
+		limit += 1
+	}
+	if limit > 0 && len(s.line)+len(data) > limit {
+		if !s.options.TruncateLongLines {
+			return s.errLineTooLong()
+		}
+		data = data[:limit-len(s.line)]
+	}
+	s.line = append(s.line, data...)
+	return nil
+}
+
+// emit completes the current line.
+func (s *lineSplitter) emit() (Line, error) {
+	line := s.line
+	if s.options.StripCR {
+		line = bytes.TrimSuffix(line, []byte{'\r'})
+	}
+	limit := s.options.MaxLineLength
+	if limit > 0 && len(line) > limit {
+		if !s.options.TruncateLongLines {
+			return Line{}, s.errLineTooLong()
+		}
+		line = line[:limit]
+	}
+	s.number += 1
+	s.line = s.line[:0]
+	return Line{Number: s.number, Text: string(line)}, nil
+}
+
+func (s *lineSplitter) errLineTooLong() error {
+	return fmt.Errorf("line %d: %w", s.number+1, ErrLineTooLong)
+}
+
+// linesOrFail returns the lines followed by the failure if any.
+func linesOrFail(lines []Line, err error) stream.Stream[Line] {
+	if err != nil {
+		return stream.AndThen(stream.FromSlice(lines), stream.Fail[Line](err))
+	} else {
+		return stream.FromSlice(lines)
+	}
+}
@@ -0,0 +1,68 @@
+package text_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+	"github.com/primetalk/goio/text"
+	"github.com/stretchr/testify/assert"
+)
+
+func readLines(t *testing.T, data string, chunkSize int, options text.LineReaderOptions) ([]text.Line, error) {
+	return io.UnsafeRunSync(stream.ToSlice(text.SplitLines(byteChunks([]byte(data), chunkSize), options)))
+}
+
+func TestSplitLinesCRLF(t *testing.T) {
+	lines, err := readLines(t, "a\r\nbb\r\n\r\nlast", 2, text.LineReaderOptions{StripCR: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []text.Line{{1, "a"}, {2, "bb"}, {3, ""}, {4, "last"}}, lines)
+
+	lines, err = readLines(t, "a\nlast", 2, text.LineReaderOptions{DropUnterminatedLastLine: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []text.Line{{1, "a"}}, lines)
+}
+
+func TestSplitLinesMultiByteDelimiter(t *testing.T) {
+	for chunkSize := 1; chunkSize < 5; chunkSize++ {
+		lines, err := readLines(t, "one<|>two<<|>three<|", chunkSize, text.LineReaderOptions{Delimiter: "<|>"})
+		assert.NoError(t, err)
+		assert.Equal(t, []text.Line{{1, "one"}, {2, "two<"}, {3, "three<|"}}, lines)
+	}
+	lines, err := readLines(t, "a\x00b\x00", 3, text.LineReaderOptions{Delimiter: "\x00"})
+	assert.NoError(t, err)
+	assert.Equal(t, []text.Line{{1, "a"}, {2, "b"}}, lines)
+}
+
+func TestSplitLinesMaxLength(t *testing.T) {
+	data := "short\n" + strings.Repeat("x", 100) + "\nok\n"
+	lines, err := readLines(t, data, 7, text.LineReaderOptions{MaxLineLength: 10, TruncateLongLines: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []text.Line{{1, "short"}, {2, "xxxxxxxxxx"}, {3, "ok"}}, lines)
+
+	lines, err = readLines(t, data, 7, text.LineReaderOptions{MaxLineLength: 10})
+	assert.ErrorIs(t, err, text.ErrLineTooLong)
+	assert.Contains(t, err.Error(), "line 2")
+	assert.Empty(t, lines)
+
+	first, err := io.UnsafeRunSync(stream.Head(text.ReadLinesWithOptions(strings.NewReader(data), text.LineReaderOptions{MaxLineLength: 10})))
+	assert.NoError(t, err)
+	assert.Equal(t, text.Line{Number: 1, Text: "short"}, first)
+}
+
+func TestSplitLinesCRLFMaxLength(t *testing.T) {
+	data := "abcd\r\nefgh\r\n"
+	for size := 1; size < 7; size++ {
+		lines, err := readLines(t, data, size, text.LineReaderOptions{StripCR: true, MaxLineLength: 4})
+		assert.NoError(t, err)
+		assert.Equal(t, []text.Line{{1, "abcd"}, {2, "efgh"}}, lines)
+	}
+
+	_, err := readLines(t, "abcde\r\n", 3, text.LineReaderOptions{StripCR: true, MaxLineLength: 4})
+	assert.ErrorIs(t, err, text.ErrLineTooLong)
+
+	lines, err := readLines(t, "abcdef\r\nabcd\r\nab", 3, text.LineReaderOptions{StripCR: true, MaxLineLength: 4, TruncateLongLines: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []text.Line{{1, "abcd"}, {2, "abcd"}, {3, "ab"}}, lines)
+}
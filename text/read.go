@@ -13,6 +13,7 @@ import (
 var emptyByteChunkStream = stream.Empty[[]byte]()
 
 // ReadByteChunks reads chunks from the reader.
+// Each element of the stream is the result of a single Read of at most chunkSize bytes.
 func ReadByteChunks(reader fio.Reader, chunkSize int) stream.Stream[[]byte] {
 	return stream.Stream[[]byte](io.Pure(func() (res stream.StepResult[[]byte]) {
 		bytes := make([]byte, chunkSize)
@@ -34,6 +35,30 @@ func ReadByteChunks(reader fio.Reader, chunkSize int) stream.Stream[[]byte] {
 	}))
 }
 
+// ReadBytes reads the reader byte-by-byte.
+// Internally bytes are delivered in chunks of DefaultChunkSize.
+// Each Read becomes a single chunk step, so there is no per-byte overhead.
+func ReadBytes(reader fio.Reader) stream.Stream[byte] {
+	return stream.Stream[byte](io.Pure(func() (res stream.StepResult[byte]) {
+		bytes := make([]byte, DefaultChunkSize)
+		cnt, err1 := reader.Read(bytes)
+		var finish stream.Stream[byte]
+		if err1 == nil {
+			finish = ReadBytes(reader)
+		} else if err1 == fio.EOF {
+			finish = stream.Empty[byte]()
+		} else {
+			finish = stream.Fail[byte](err1)
+		}
+		if cnt == 0 {
+			res = stream.NewStepResultEmpty(finish)
+		} else {
+			res = stream.NewStepResultChunk(bytes[0:cnt], finish)
+		}
+		return
+	}))
+}
+
 // SplitBySeparator splits byte-chunk stream by the given separator.
 func SplitBySeparator(stm stream.Stream[[]byte], sep byte, shouldReturnLastIncompleteLine bool) stream.Stream[[]byte] {
 	return stream.StateFlatMapWithFinish(stm, []byte{},
@@ -0,0 +1,59 @@
+package text_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/stream"
+	"github.com/primetalk/goio/text"
+	"github.com/stretchr/testify/assert"
+)
+
+// benchmarkText has 10000 lines of 44 bytes each.
+var benchmarkText = []byte(strings.Repeat("the quick brown fox jumps over the lazy dog\n", 10000))
+
+// oneStepPerElement delivers each element in a separate step.
+func oneStepPerElement[A any](stm stream.Stream[A]) stream.Stream[A] {
+	return stream.FlatMap(stm, stream.Lift[A])
+}
+
+func isSpace(b byte) bool { return b == ' ' }
+
+func countSpaces(b *testing.B, stm stream.Stream[byte]) {
+	res, err := io.UnsafeRunSync(stream.Head(stream.Len(stream.Filter(stm, isSpace))))
+	assert.NoError(b, err)
+	assert.Equal(b, 80000, res)
+}
+
+func BenchmarkReadBytes(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		countSpaces(b, text.ReadBytes(bytes.NewReader(benchmarkText)))
+	}
+}
+
+func BenchmarkReadBytesOneStepPerByte(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		countSpaces(b, oneStepPerElement(text.ReadBytes(bytes.NewReader(benchmarkText))))
+	}
+}
+
+func countLongLines(b *testing.B, stm stream.Stream[string]) {
+	long := stream.Filter(stream.Map(stm, func(s string) int { return len(s) }), func(l int) bool { return l > 40 })
+	res, err := io.UnsafeRunSync(stream.Head(stream.Len(long)))
+	assert.NoError(b, err)
+	assert.Equal(b, 10000, res)
+}
+
+func BenchmarkReadLines(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		countLongLines(b, text.ReadLines(bytes.NewReader(benchmarkText)))
+	}
+}
+
+func BenchmarkReadLinesOneStepPerLine(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		countLongLines(b, oneStepPerElement(text.ReadLines(bytes.NewReader(benchmarkText))))
+	}
+}
@@ -0,0 +1,69 @@
+package text
+
+import (
+	fio "io"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/option"
+	"github.com/primetalk/goio/resource"
+	"github.com/primetalk/goio/stream"
+)
+
+// ToReader returns a reader that pulls byte chunks from the stream on demand.
+// This allows to pass a stream to APIs that take io.Reader.
+// The rest of the stream is finalized when the reader is closed or the resource is released.
+// A failure of the stream is returned from Read.
+func ToReader(stm stream.Stream[[]byte]) resource.Resource[fio.ReadCloser] {
+	return resource.Map(stream.ToCursor(stm), func(cursor stream.Cursor[[]byte]) fio.ReadCloser {
+		return &chunkReader{cursor: cursor}
+	})
+}
+
+// FromWriterFunc runs a writer-based producer in a separate fiber and returns the stream
+// of bytes that it writes. The producer is blocked until the data is consumed.
+// When the producer fails, the stream fails with the same error.
+// When the stream is abandoned, subsequent writes of the producer fail with io.ErrClosedPipe.
+func FromWriterFunc(produce func(fio.Writer) error) stream.Stream[[]byte] {
+	return stream.Stream[[]byte](io.Delay(func() io.IO[stream.StepResult[[]byte]] {
+		pr, pw := fio.Pipe()
+		producer := io.FromUnit(func() error {
+			return pw.CloseWithError(produce(pw))
+		})
+		closeReader := io.FromUnit(pr.Close)
+		return io.AndThen(
+			io.FireAndForget(producer),
+			io.IO[stream.StepResult[[]byte]](stream.OnFinalize(ReadByteChunks(pr, DefaultChunkSize), closeReader)),
+		)
+	}))
+}
+
+// chunkReader is an io.ReadCloser that pulls byte chunks from the cursor on demand.
+type chunkReader struct {
+	cursor  stream.Cursor[[]byte]
+	current []byte
+}
+
+func (r *chunkReader) Read(p []byte) (n int, err error) {
+	for len(r.current) == 0 {
+		var next option.Option[[]byte]
+		next, err = io.UnsafeRunSync(r.cursor.Next())
+		if err != nil {
+			return
+		} else if option.IsEmpty(next) {
+			return 0, fio.EOF
+		}
+		r.current = option.Get(next)
+	}
+	n = copy(p, r.current)
+	r.current = r.current[n:]
+	return
+}
+
+// Close finalizes the rest of the stream.
+func (r *chunkReader) Close() error {
+	r.current = nil
+	_, err := io.UnsafeRunSync(r.cursor.Close())
+	return err
+}
+
+var _ fio.ReadCloser = (*chunkReader)(nil)
@@ -0,0 +1,75 @@
+package text_test
+
+import (
+	"errors"
+	fio "io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/resource"
+	"github.com/primetalk/goio/stream"
+	"github.com/primetalk/goio/text"
+	"github.com/stretchr/testify/assert"
+)
+
+var errExpected = errors.New("expected error")
+
+func TestToReader(t *testing.T) {
+	var count int32
+	finalizer := io.FromPureEffect(func() { atomic.AddInt32(&count, 1) })
+	stm := stream.OnFinalize(byteChunks(compressibleText, 100), finalizer)
+	data, err := io.UnsafeRunSync(resource.Use(text.ToReader(stm), func(r fio.ReadCloser) io.IO[[]byte] {
+		return io.Eval(func() ([]byte, error) {
+			return fio.ReadAll(r)
+		})
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, compressibleText, data)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	failed := stream.AndThen(byteChunks([]byte("abc"), 2), stream.Fail[[]byte](errExpected))
+	_, err = io.UnsafeRunSync(resource.Use(text.ToReader(failed), func(r fio.ReadCloser) io.IO[[]byte] {
+		return io.Eval(func() ([]byte, error) {
+			return fio.ReadAll(r)
+		})
+	}))
+	assert.ErrorIs(t, err, errExpected)
+}
+
+func TestFromWriterFunc(t *testing.T) {
+	stm := text.FromWriterFunc(func(w fio.Writer) error {
+		for i := 0; i < 10; i++ {
+			_, err := w.Write(compressibleText[i*100 : (i+1)*100])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.Equal(t, compressibleText[:1000], concatBytes(t, stm))
+
+	failed := text.FromWriterFunc(func(w fio.Writer) error {
+		_, _ = w.Write([]byte("abc"))
+		return errExpected
+	})
+	_, err := io.UnsafeRunSync(stream.ToSlice(failed))
+	assert.ErrorIs(t, err, errExpected)
+}
+
+func TestFromWriterFuncAbandoned(t *testing.T) {
+	producerErr := make(chan error, 1)
+	infinite := text.FromWriterFunc(func(w fio.Writer) error {
+		for {
+			_, err := w.Write([]byte("data"))
+			if err != nil {
+				producerErr <- err
+				return err
+			}
+		}
+	})
+	chunks, err := io.UnsafeRunSync(stream.ToSlice(stream.Take(infinite, 3)))
+	assert.NoError(t, err)
+	assert.Len(t, chunks, 3)
+	assert.True(t, errors.Is(<-producerErr, fio.ErrClosedPipe))
+}
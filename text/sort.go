@@ -0,0 +1,121 @@
+package text
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/primetalk/goio/fun"
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/resource"
+	"github.com/primetalk/goio/slice"
+	"github.com/primetalk/goio/stream"
+)
+
+// ErrEncodedValueContainsNewline is returned when an encoded value cannot be saved as a single line.
+var ErrEncodedValueContainsNewline = errors.New("encoded value contains '\\n'")
+
+// Codec converts values to single lines of text and back.
+// Encoded values should not contain '\n'. Otherwise ExternalSort fails with ErrEncodedValueContainsNewline.
+type Codec[A any] struct {
+	Encode func(A) (string, error)
+	Decode func(string) (A, error)
+}
+
+// StringCodec keeps strings as is.
+var StringCodec = Codec[string]{
+	Encode: func(s string) (string, error) { return s, nil },
+	Decode: func(s string) (string, error) { return s, nil },
+}
+
+// IntCodec converts ints to decimal strings.
+var IntCodec = Codec[int]{
+	Encode: func(i int) (string, error) { return strconv.Itoa(i), nil },
+	Decode: strconv.Atoi,
+}
+
+// TempDir returns a resource for a new temporary directory.
+// The directory is removed together with all it's contents on release.
+func TempDir(dir, pattern string) resource.Resource[string] {
+	return resource.NewResource(
+		io.Eval(func() (string, error) {
+			return os.MkdirTemp(dir, pattern)
+		}),
+		func(path string) io.IO[fun.Unit] {
+			return io.FromUnit(func() error {
+				return os.RemoveAll(path)
+			})
+		},
+	)
+}
+
+// ExternalSort sorts a stream that might not fit in memory.
+// Chunks of chunkSize elements are sorted in memory and saved to temporary files.
+// Then the files are merged lazily using stream.MergeSorted.
+// The sort is stable.
+// Temporary files are removed when the result stream completes, fails or is abandoned.
+func ExternalSort[A any](stm stream.Stream[A], less func(A, A) bool, chunkSize int, codec Codec[A]) stream.Stream[A] {
+	return stream.UseResource(TempDir("", "goio-sort-"), func(dir string) stream.Stream[A] {
+		chunks := stream.Filter(stream.ToChunks[A](chunkSize)(stm), func(as []A) bool { return len(as) > 0 })
+		indexed := stream.ZipWithIndex(chunks)
+		paths := stream.MapEval(indexed, func(p fun.Pair[int, []A]) io.IO[string] {
+			path := filepath.Join(dir, "chunk-"+strconv.Itoa(p.V1))
+			return io.Map(saveSortedChunk(path, p.V2, less, codec), fun.Const[fun.Unit](path))
+		})
+		return stream.FlatMap(stream.Eval(stream.ToSlice(paths)), func(paths []string) stream.Stream[A] {
+			return stream.MergeSorted(less, slice.Map(paths, func(path string) stream.Stream[A] {
+				return readEncodedFile(path, codec)
+			})...)
+		})
+	})
+}
+
+// saveSortedChunk sorts the chunk and writes it to a new file.
+func saveSortedChunk[A any](path string, chunk []A, less func(A, A) bool, codec Codec[A]) io.IOUnit {
+	return resource.Use(createFile(path), func(f *os.File) io.IOUnit {
+		sort.SliceStable(chunk, func(i, j int) bool { return less(chunk[i], chunk[j]) })
+		w := bufio.NewWriter(f)
+		lines := stream.MapEval(stream.FromSlice(chunk), func(a A) io.IO[string] {
+			return io.Eval(func() (line string, err error) {
+				line, err = codec.Encode(a)
+				if err == nil && strings.IndexByte(line, '\n') != -1 {
+					err = fmt.Errorf("%w: %q", ErrEncodedValueContainsNewline, line)
+				}
+				return
+			})
+		})
+		return io.AndThen(
+			stream.DrainAll(stream.ToSink(lines, WriteLines(w))),
+			io.FromUnit(w.Flush),
+		)
+	})
+}
+
+// createFile returns a resource for a new file.
+func createFile(name string) resource.Resource[*os.File] {
+	return resource.NewResource(
+		io.Eval(func() (*os.File, error) {
+			return os.Create(name)
+		}),
+		func(f *os.File) io.IO[fun.Unit] {
+			return io.FromUnit(func() error {
+				return f.Close()
+			})
+		},
+	)
+}
+
+// readEncodedFile reads and decodes all lines of the file.
+// The file is closed when the stream is finalized.
+func readEncodedFile[A any](path string, codec Codec[A]) stream.Stream[A] {
+	return stream.UseResource(ReadOnlyFile(path), func(f *os.File) stream.Stream[A] {
+		return stream.MapEval(ReadLines(bufio.NewReader(f)), func(line string) io.IO[A] {
+			return io.Eval(func() (A, error) { return codec.Decode(line) })
+		})
+	})
+}
@@ -0,0 +1,50 @@
+package text_test
+
+import (
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/primetalk/goio/io"
+	"github.com/primetalk/goio/slice"
+	"github.com/primetalk/goio/stream"
+	"github.com/primetalk/goio/text"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalSort(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	values := rand.New(rand.NewSource(1)).Perm(1000)
+	sorted := text.ExternalSort(stream.FromSlice(values), func(a, b int) bool { return a < b }, 64, text.IntCodec)
+	res, err := io.UnsafeRunSync(stream.ToSlice(sorted))
+	assert.NoError(t, err)
+	assert.Equal(t, slice.Range(0, 1000), res)
+	assertEmptyDir(t, tmp)
+
+	words := strings.Fields("the quick brown fox jumps over the lazy dog")
+	sortedWords := text.ExternalSort(stream.FromSlice(words), func(a, b string) bool { return a < b }, 2, text.StringCodec)
+	first3, err := io.UnsafeRunSync(stream.ToSlice(stream.Take(sortedWords, 3)))
+	assert.NoError(t, err)
+	expected := append([]string{}, words...)
+	sort.Strings(expected)
+	assert.Equal(t, expected[:3], first3)
+	assertEmptyDir(t, tmp)
+}
+
+func TestExternalSortNewline(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	sorted := text.ExternalSort(stream.LiftMany("b", "a\nc"), func(a, b string) bool { return a < b }, 1, text.StringCodec)
+	_, err := io.UnsafeRunSync(stream.ToSlice(sorted))
+	assert.ErrorIs(t, err, text.ErrEncodedValueContainsNewline)
+	assertEmptyDir(t, tmp)
+}
+
+func assertEmptyDir(t *testing.T, dir string) {
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}